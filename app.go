@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTLifetime is the lifetime of the JWT of a GitHub App. GitHub
+	// accepts up to 10 minutes.
+	appJWTLifetime = 9 * time.Minute
+
+	// appJWTClockSkew backdates the JWT against clock drift from GitHub.
+	appJWTClockSkew = time.Minute
+
+	// appTokenEarlyExpiry is how long before its expiry an installation
+	// token is refreshed, so that a request in a long upload does not use
+	// an expired one.
+	appTokenEarlyExpiry = 5 * time.Minute
+)
+
+// AppTokenSource is an oauth2.TokenSource of installation tokens of a GitHub
+// App, which are valid for an hour. Wrap it with NewAppTokenSource to reuse
+// a token until shortly before it expires.
+type AppTokenSource struct {
+	// AppID is the App ID or the Client ID of the GitHub App.
+	AppID      string
+	PrivateKey *rsa.PrivateKey
+
+	// InstallationID is the installation of the App which can access the
+	// repository. It's looked up from the repository when zero.
+	InstallationID int64
+
+	Owner, Repo string
+	BaseURL     *url.URL
+
+	// HTTPClient sends requests as the App. http.DefaultClient is used
+	// when nil.
+	HTTPClient *http.Client
+
+	// Ctx cancels the requests for tokens, e.g., on SIGINT.
+	// context.Background() is used when nil.
+	Ctx context.Context
+
+	// RetryPolicy is applied to the requests for tokens.
+	RetryPolicy RetryPolicy
+
+	mu sync.Mutex
+}
+
+// NewAppTokenSource returns the token source which refreshes installation
+// tokens of src transparently.
+func NewAppTokenSource(src *AppTokenSource) oauth2.TokenSource {
+	return oauth2.ReuseTokenSourceWithExpiry(nil, src, appTokenEarlyExpiry)
+}
+
+// Token creates a new installation token. The token can access only the
+// repository.
+func (s *AppTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(s.HTTPClient).WithAuthToken(jwt)
+	if s.BaseURL != nil {
+		client.BaseURL = s.BaseURL
+	}
+
+	ctx := s.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s.InstallationID == 0 {
+		var installation *github.Installation
+		err := s.RetryPolicy.Do(ctx, true, func() (res *github.Response, err error) {
+			installation, res, err = client.Apps.FindRepositoryInstallation(ctx, s.Owner, s.Repo)
+			return res, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the installation of the GitHub App for %s/%s: %w", s.Owner, s.Repo, err)
+		}
+		s.InstallationID = installation.GetID()
+		Debugf("GitHub App installation: %d", s.InstallationID)
+	}
+
+	// Creating another token is harmless, so it's retried like idempotent
+	// requests.
+	var token *github.InstallationToken
+	err = s.RetryPolicy.Do(ctx, true, func() (res *github.Response, err error) {
+		token, res, err = client.Apps.CreateInstallationToken(ctx, s.InstallationID, &github.InstallationTokenOptions{
+			Repositories: []string{s.Repo},
+		})
+		return res, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create an installation token of the GitHub App: %w", err)
+	}
+	Debugf("GitHub App installation token expires at %s", token.GetExpiresAt())
+
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "token",
+		Expiry:      token.GetExpiresAt().Time,
+	}, nil
+}
+
+// jwt signs the JWT which authenticates as the App with RS256.
+func (s *AppTokenSource) jwt(now time.Time) (string, error) {
+	// The App ID is a number in the JWT, while the Client ID is a string.
+	var iss interface{} = s.AppID
+	if id, err := strconv.ParseInt(s.AppID, 10, 64); err == nil {
+		iss = id
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": iss,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the JWT of the GitHub App: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// ReadAppPrivateKey reads the private key of a GitHub App in PEM. s is
+// either the PEM itself, e.g., from an env var, or the path to the file.
+func ReadAppPrivateKey(s string) (*rsa.PrivateKey, error) {
+	data := []byte(s)
+	if !strings.HasPrefix(strings.TrimSpace(s), "-----BEGIN") {
+		var err error
+		data, err = os.ReadFile(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the private key of the GitHub App: %w", err)
+		}
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid private key of the GitHub App: no PEM data")
+	}
+
+	// GitHub generates keys in PKCS #1, but converted ones may be PKCS #8.
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key of the GitHub App: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid private key of the GitHub App: not an RSA key")
+	}
+	return rsaKey, nil
+}
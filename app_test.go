@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// verify checks the JWT signed by the App and returns its claims.
+	verify := func(r *http.Request) (map[string]interface{}, error) {
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			return nil, fmt.Errorf("no JWT: %q", r.Header.Get("Authorization"))
+		}
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid JWT: %q", jwt)
+		}
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, err
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		var claims map[string]interface{}
+		return claims, json.Unmarshal(payload, &claims)
+	}
+
+	var tokens atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := verify(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if claims["iss"] != float64(1234) {
+			http.Error(w, fmt.Sprintf("iss: %v", claims["iss"]), http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/tcnksm/ghr/installation":
+			fmt.Fprint(w, `{"id": 42}`)
+		case "POST /app/installations/42/access_tokens":
+			var opts struct{ Repositories []string }
+			if err := json.NewDecoder(r.Body).Decode(&opts); err != nil || len(opts.Repositories) != 1 || opts.Repositories[0] != "ghr" {
+				http.Error(w, "invalid repositories", http.StatusUnprocessableEntity)
+				return
+			}
+			// Expires sooner than the early expiry, so that every request
+			// refreshes the token.
+			n := tokens.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, n,
+				time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	ts := NewAppTokenSource(&AppTokenSource{
+		AppID:      "1234",
+		PrivateKey: key,
+		Owner:      "tcnksm",
+		Repo:       "ghr",
+		BaseURL:    baseURL,
+	})
+
+	for i := 1; i <= 2; i++ {
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatalf("Token failed: %s", err)
+		}
+		if want := fmt.Sprintf("ghs_%d", i); token.AccessToken != want {
+			t.Fatalf("Token = %q; want refreshed %q", token.AccessToken, want)
+		}
+	}
+}
+
+func TestReadAppPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs1PEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	pkcs8PEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+
+	path := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(path, []byte(pkcs1PEM), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{pkcs1PEM, pkcs8PEM, path} {
+		got, err := ReadAppPrivateKey(s)
+		if err != nil {
+			t.Fatalf("ReadAppPrivateKey failed: %s", err)
+		}
+		if !got.Equal(key) {
+			t.Fatal("ReadAppPrivateKey returns a different key")
+		}
+	}
+
+	for _, s := range []string{filepath.Join(t.TempDir(), "missing.pem"), "-----BEGIN garbage"} {
+		if _, err := ReadAppPrivateKey(s); err == nil {
+			t.Fatalf("expect ReadAppPrivateKey to fail: %q", s)
+		}
+	}
+}
+
+func TestAppTokenSource_retry(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first request for a token fails with a transient error.
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_retried", "expires_at": %q}`,
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	src := &AppTokenSource{
+		AppID:          "1234",
+		PrivateKey:     key,
+		InstallationID: 42,
+		Owner:          "tcnksm",
+		Repo:           "ghr",
+		BaseURL:        baseURL,
+		RetryPolicy:    RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxWait: time.Second},
+	}
+
+	token, err := src.Token()
+	if err != nil {
+		t.Fatalf("Token failed: %s", err)
+	}
+	if token.AccessToken != "ghs_retried" {
+		t.Fatalf("Token = %q; want ghs_retried", token.AccessToken)
+	}
+
+	// The request is canceled with the context, e.g., on SIGINT.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	src.Ctx = ctx
+	if _, err := src.Token(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Token = %v; want context.Canceled", err)
+	}
+}
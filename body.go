@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadBodyFile reads the release body from path. "-" means stdin.
+func ReadBodyFile(path string, stdin io.Reader) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if path == "-" {
+		b, err = io.ReadAll(stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+	return string(b), nil
+}
+
+// ReadChangelogSection reads the section of the tag from the changelog file.
+func ReadChangelogSection(path, tag string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open changelog: %w", err)
+	}
+	defer f.Close()
+
+	body, err := ChangelogSection(f, tag)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return body, nil
+}
+
+// ChangelogSection extracts the section whose heading is the tag from a
+// changelog in Markdown. Headings in the style of Keep a Changelog
+// (e.g., "## [1.0.0] - 2017-06-20") and tagpr (e.g., "## [v0.18.3](URL) - 2026-04-15")
+// are supported, as well as plain ones (e.g., "## v1.0.0"). The leading 'v'
+// of versions is ignored when comparing with the tag. The heading itself is
+// not included.
+func ChangelogSection(r io.Reader, tag string) (string, error) {
+	var (
+		lines []string
+		found bool
+		level int
+
+		// fence is the fence of the code block the line is in, if any.
+		// Lines like "# comment" in code blocks are not headings.
+		fence string
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		l, title := 0, ""
+		if fence != "" {
+			if closesFence(line, fence) {
+				fence = ""
+			}
+		} else if fence = codeFence(line); fence == "" {
+			l, title = markdownHeading(line)
+		}
+
+		if found {
+			if l != 0 && l <= level {
+				break
+			}
+			lines = append(lines, line)
+			continue
+		}
+		if l != 0 && sameVersion(headingVersion(title), tag) {
+			found, level = true, l
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read changelog: %w", err)
+	}
+
+	if !found {
+		return "", fmt.Errorf("no section found for %s in changelog", tag)
+	}
+	section := strings.TrimSpace(strings.Join(lines, "\n"))
+	if section == "" {
+		return "", fmt.Errorf("section for %s in changelog is empty", tag)
+	}
+	return section, nil
+}
+
+// codeFence returns the opening fence of a fenced code block, e.g., "```"
+// for "```go", or "" when the line does not open one.
+func codeFence(line string) string {
+	line = strings.TrimLeft(line, " ")
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n < 3 {
+			continue
+		}
+		// The info string of a backtick fence can not contain backticks.
+		if c == "`" && strings.Contains(line[n:], "`") {
+			return ""
+		}
+		return line[:n]
+	}
+	return ""
+}
+
+// closesFence reports whether the line closes the code block opened by
+// fence: the same character at least as many times with nothing after.
+func closesFence(line, fence string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == ""
+}
+
+// markdownHeading returns the level and the title of an ATX heading, or 0
+// when the line is not a heading.
+func markdownHeading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(rest)
+}
+
+// headingVersion returns the version at the beginning of a heading title:
+// the link text when the title starts with '[', or the first word otherwise.
+func headingVersion(title string) string {
+	if strings.HasPrefix(title, "[") {
+		if i := strings.Index(title, "]"); i > 0 {
+			return strings.TrimSpace(title[1:i])
+		}
+		return ""
+	}
+	if i := strings.IndexAny(title, " \t("); i >= 0 {
+		return title[:i]
+	}
+	return title
+}
+
+func sameVersion(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return a == b || strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
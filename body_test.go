@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testChangelog = `# Changelog
+
+## [Unreleased]
+- Work in progress
+
+## [v0.18.3](https://github.com/tcnksm/ghr/compare/v0.18.2...v0.18.3) - 2026-04-15
+- go 1.26 by @Songmu in https://github.com/tcnksm/ghr/pull/191
+
+## [1.2.0] - 2017-07-01
+~~~sh
+# Install
+## [1.1.0]
+~~~
+
+## [1.1.1] - 2017-06-21
+
+## [1.1.0] - 2017-06-20
+### Added
+- New feature
+
+### Fixed
+- Bug
+
+## v1.0.0 (2017-01-01)
+Initial release
+`
+
+func TestChangelogSection(t *testing.T) {
+	cases := []struct {
+		tag  string
+		want string
+	}{
+		// 0: tagpr style heading
+		{"v0.18.3", "- go 1.26 by @Songmu in https://github.com/tcnksm/ghr/pull/191"},
+
+		// 1: Keep a Changelog style heading without 'v' includes subsections
+		{"v1.1.0", "### Added\n- New feature\n\n### Fixed\n- Bug"},
+
+		// 2: Plain heading at the end of the file
+		{"1.0.0", "Initial release"},
+
+		// 3: Lines like headings in code blocks are not headings
+		{"v1.2.0", "~~~sh\n# Install\n## [1.1.0]\n~~~"},
+	}
+
+	for i, tc := range cases {
+		got, err := ChangelogSection(strings.NewReader(testChangelog), tc.tag)
+		if err != nil {
+			t.Fatalf("#%d ChangelogSection failed: %s", i, err)
+		}
+		if got != tc.want {
+			t.Fatalf("#%d ChangelogSection = %q; want %q", i, got, tc.want)
+		}
+	}
+
+	if _, err := ChangelogSection(strings.NewReader(testChangelog), "v0.18"); err == nil {
+		t.Fatal("expect ChangelogSection to fail when no section is found")
+	}
+
+	if _, err := ChangelogSection(strings.NewReader(testChangelog), "v1.1.1"); err == nil {
+		t.Fatal("expect ChangelogSection to fail when the section is empty")
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	cases := map[string]string{
+		"```":       "```",
+		"````go":    "````",
+		"  ~~~ sh":  "~~~",
+		"``":        "",
+		"```go```":  "",
+		"# Heading": "",
+		"- ```item": "",
+	}
+	for line, want := range cases {
+		if got := codeFence(line); got != want {
+			t.Errorf("codeFence(%q) = %q; want %q", line, got, want)
+		}
+	}
+
+	if !closesFence("````", "```") || closesFence("```go", "```") || closesFence("~~~", "```") {
+		t.Error("closesFence does not match the fences")
+	}
+}
+
+func TestReadBodyFile_stdin(t *testing.T) {
+	got, err := ReadBodyFile("-", strings.NewReader("line 1\n\nline 2\n"))
+	if err != nil {
+		t.Fatalf("ReadBodyFile failed: %s", err)
+	}
+	if want := "line 1\n\nline 2\n"; got != want {
+		t.Fatalf("ReadBodyFile = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thediveo/enumflag/v2"
+)
+
+type ChecksumAlgorithm enumflag.Flag
+
+const (
+	checksumSHA256 ChecksumAlgorithm = iota
+	checksumSHA512
+)
+
+var ChecksumAlgorithmIds = map[ChecksumAlgorithm][]string{
+	checksumSHA256: {"sha256"},
+	checksumSHA512: {"sha512"},
+}
+
+// New returns a new hash.Hash computing the checksum.
+func (a ChecksumAlgorithm) New() hash.Hash {
+	if a == checksumSHA512 {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// DefaultFilename returns the conventional name of a checksums file, e.g.,
+// SHA256SUMS.
+func (a ChecksumAlgorithm) DefaultFilename() string {
+	if a == checksumSHA512 {
+		return "SHA512SUMS"
+	}
+	return "SHA256SUMS"
+}
+
+func (a ChecksumAlgorithm) String() string {
+	return ChecksumAlgorithmIds[a][0]
+}
+
+// ValidateChecksumFilename checks the asset name of the checksums file given
+// by the user. The file is written in a temporary directory with the name,
+// so it must not be a path. Like other assets, it must also be kept as is by
+// GitHub, or the checksums file can't be found by name after the upload.
+func ValidateChecksumFilename(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid checksums file name %q: must be a file name without path separators", name)
+	}
+	if renamed := githubAssetName(name); renamed != name {
+		return fmt.Errorf("invalid checksums file name %q: GitHub renames the asset to %q", name, renamed)
+	}
+	return nil
+}
+
+// FileChecksum returns the hex encoded checksum of the file.
+func FileChecksum(filename string, algo ChecksumAlgorithm) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	h := algo.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read file: %s %w", filename, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksums writes checksums of the local assets to w in the format of
+// sha256sum(1) and sha512sum(1). Each line is keyed by the asset name, i.e.,
+// the base name of the local file.
+func WriteChecksums(w io.Writer, localAssets []string, algo ChecksumAlgorithm) error {
+	assets := append([]string{}, localAssets...)
+	sort.Slice(assets, func(i, j int) bool {
+		return filepath.Base(assets[i]) < filepath.Base(assets[j])
+	})
+
+	for _, localAsset := range assets {
+		sum, err := FileChecksum(localAsset, algo)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s\n", sum, filepath.Base(localAsset)); err != nil {
+			return fmt.Errorf("failed to write checksum: %w", err)
+		}
+	}
+	return nil
+}
+
+// ParseChecksums parses checksums in the format of sha256sum(1) and
+// sha512sum(1) and returns them keyed by file name.
+func ParseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid checksums line %d: %q", n, line)
+		}
+		if _, err := hex.DecodeString(sum); err != nil {
+			return nil, fmt.Errorf("invalid checksums line %d: %q", n, line)
+		}
+
+		// The file name is prefixed with ' ' in text mode and '*' in
+		// binary mode.
+		name = strings.TrimPrefix(strings.TrimPrefix(name, " "), "*")
+		checksums[name] = strings.ToLower(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return checksums, nil
+}
+
+// checksumAlgorithmOf returns the algorithm of a hex encoded checksum guessed
+// from its length.
+func checksumAlgorithmOf(sum string) (ChecksumAlgorithm, bool) {
+	switch len(sum) {
+	case hex.EncodedLen(sha256.Size):
+		return checksumSHA256, true
+	case hex.EncodedLen(sha512.Size):
+		return checksumSHA512, true
+	}
+	return 0, false
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteChecksums(t *testing.T) {
+	cases := []struct {
+		localAssets []string
+		algo        ChecksumAlgorithm
+		want        string
+	}{
+		{
+			[]string{filepath.Join(TestDir, "linux_386"), filepath.Join(TestDir, "darwin_386")},
+			checksumSHA256,
+			"21fb70167c05fa4e1d4f22b6da937d73e293c974bc56dfb505425bd6be9301d9  darwin_386\n" +
+				"d88c94dcd6c78cf35c5a04690c25a113d63c2ac68cc9aff98c413aad30fb708b  linux_386\n",
+		},
+		{
+			[]string{filepath.Join(TestDir, "darwin_386")},
+			checksumSHA512,
+			"28276231b0959bf200058b48bd3349aef237dbe2a3204495010f591bd3a0dd7444f5af6942eac9841b8db2b1e259a3490d6290af4f312f8cbd3a5cb115078329  darwin_386\n",
+		},
+	}
+
+	for i, tc := range cases {
+		var buf bytes.Buffer
+		if err := WriteChecksums(&buf, tc.localAssets, tc.algo); err != nil {
+			t.Fatalf("#%d WriteChecksums failed: %s", i, err)
+		}
+
+		if got := buf.String(); got != tc.want {
+			t.Fatalf("#%d WriteChecksums = %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	in := "21fb70167c05fa4e1d4f22b6da937d73e293c974bc56dfb505425bd6be9301d9  darwin_386\n" +
+		"\n" +
+		"D88C94DCD6C78CF35C5A04690C25A113D63C2AC68CC9AFF98C413AAD30FB708B *linux_386\n"
+
+	checksums, err := ParseChecksums(strings.NewReader(in))
+	if err != nil {
+		t.Fatal("ParseChecksums failed:", err)
+	}
+
+	want := map[string]string{
+		"darwin_386": "21fb70167c05fa4e1d4f22b6da937d73e293c974bc56dfb505425bd6be9301d9",
+		"linux_386":  "d88c94dcd6c78cf35c5a04690c25a113d63c2ac68cc9aff98c413aad30fb708b",
+	}
+	if !reflect.DeepEqual(checksums, want) {
+		t.Fatalf("ParseChecksums = %v, want %v", checksums, want)
+	}
+
+	if _, err := ParseChecksums(strings.NewReader("not a checksum\n")); err == nil {
+		t.Fatal("ParseChecksums expects error for invalid line")
+	}
+}
+
+func TestValidateChecksumFilename(t *testing.T) {
+	for _, name := range []string{"SHA256SUMS", "ghr_0.1.0_checksums.txt", "sha256+sums"} {
+		if err := ValidateChecksumFilename(name); err != nil {
+			t.Fatalf("ValidateChecksumFilename(%q) failed: %s", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "../SHA256SUMS", "dist/SHA256SUMS", `dist\SHA256SUMS`, "my sums", "..SUMS", "SUMS~"} {
+		if err := ValidateChecksumFilename(name); err == nil {
+			t.Fatalf("expect ValidateChecksumFilename(%q) to fail", name)
+		}
+	}
+}
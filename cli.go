@@ -10,13 +10,17 @@ import (
 	"io"
 	"log"
 	"os"
-	"regexp"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/google/go-github/v66/github"
 	"github.com/mitchellh/colorstring"
-	"github.com/tcnksm/go-gitconfig"
 	"github.com/thediveo/enumflag/v2"
 )
 
@@ -45,12 +49,17 @@ const (
 	ExitCodeOwnerNotFound
 	ExitCodeRepoNotFound
 	ExitCodeReleaseError
+	ExitCodeInvalidAssets
+	ExitCodeUploadFailed
 )
 
 const (
 	defaultCheckTimeout = 2 * time.Second
 	defaultBaseURL      = "https://api.github.com/"
 	defaultParallel     = -1
+
+	// defaultCleanupTimeout is the timeout to clean up after ghr fails.
+	defaultCleanupTimeout = 30 * time.Second
 )
 
 type SetLatest enumflag.Flag
@@ -81,8 +90,50 @@ func PrintRedf(w io.Writer, format string, args ...interface{}) {
 		colorstring.Color(fmt.Sprintf(format, args...)))
 }
 
+// stringsFlag is a flag.Value which can be specified multiple times.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// prereleaseFlag is the value of -prerelease: true, false or auto. It's a
+// boolean flag so that '-prerelease' alone still means true.
+type prereleaseFlag string
+
+const prereleaseAuto prereleaseFlag = "auto"
+
+func (p *prereleaseFlag) String() string {
+	return string(*p)
+}
+
+func (p *prereleaseFlag) Set(v string) error {
+	if strings.EqualFold(v, string(prereleaseAuto)) {
+		*p = prereleaseAuto
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("must be true, false or auto: %q", v)
+	}
+	*p = prereleaseFlag(strconv.FormatBool(b))
+	return nil
+}
+
+func (p *prereleaseFlag) IsBoolFlag() bool {
+	return true
+}
+
 // CLI is the main command line object
 type CLI struct {
+	// inStream is stdin, from which '-body-file -' reads the body.
+	inStream io.Reader
+
 	// outStream and errStream correspond to stdout and stderr, respectively,
 	// to take messages from the CLI.
 	outStream, errStream io.Writer
@@ -90,47 +141,72 @@ type CLI struct {
 
 // Run invokes the CLI with the given arguments.
 func (cli *CLI) Run(args []string) int {
+	if len(args) > 1 {
+		switch args[1] {
+		case "download":
+			return cli.runDownload(args[2:])
+		case "list":
+			return cli.runList(args[2:])
+		case "show":
+			return cli.runShow(args[2:])
+		case "prune":
+			return cli.runPrune(args[2:])
+		}
+	}
 
 	var (
-		owner string
-		repo  string
-		token string
+		repository repositoryFlags
 
 		commitish  string
 		name       string
 		body       string
+		bodyFile   string
+		changelog  string
 		draft      bool
 		prerelease bool
 		latest     SetLatest
 
+		prereleaseValue       prereleaseFlag = "false"
+		prereleaseIdentifiers string
+
+		latestTagPrefix string
+
 		parallel int
 
-		recreate bool
-		replace  bool
-		soft     bool
+		recursive bool
+		include   stringsFlag
+		exclude   stringsFlag
+
+		checksum          bool
+		checksumFile      string
+		checksumAlgorithm ChecksumAlgorithm
 
+		recreate     bool
+		update       bool
+		replace      bool
+		skipExisting bool
+		soft         bool
+
+		keepPartial bool
+		keepGoing   bool
+
+		dryRun  bool
+		output  OutputFormat
 		stat    bool
 		version bool
 		debug   bool
 
 		generatenotes bool
+		templated     bool
 	)
 
 	flags := flag.NewFlagSet(Name, flag.ContinueOnError)
 	flags.SetOutput(cli.errStream)
 	flags.Usage = func() {
-		fmt.Fprint(cli.errStream, helpText)
+		fmt.Fprint(cli.errStream, EnvHelp(helpText, flags))
 	}
 
-	flags.StringVar(&owner, "username", "", "")
-	flags.StringVar(&owner, "owner", "", "")
-	flags.StringVar(&owner, "u", "", "")
-
-	flags.StringVar(&repo, "repository", "", "")
-	flags.StringVar(&repo, "r", "", "")
-
-	flags.StringVar(&token, "token", os.Getenv(EnvGitHubToken), "")
-	flags.StringVar(&token, "t", os.Getenv(EnvGitHubToken), "")
+	repository.register(flags)
 
 	flags.StringVar(&commitish, "commitish", "", "")
 	flags.StringVar(&commitish, "c", "", "")
@@ -140,26 +216,55 @@ func (cli *CLI) Run(args []string) int {
 
 	flags.StringVar(&body, "body", "", "")
 	flags.StringVar(&body, "b", "", "")
+	flags.StringVar(&bodyFile, "body-file", "", "")
+	flags.StringVar(&changelog, "body-from-changelog", "", "")
+	flags.BoolVar(&templated, "template", false, "")
 
 	flags.BoolVar(&draft, "draft", false, "")
-	flags.BoolVar(&prerelease, "prerelease", false, "")
+	flags.Var(&prereleaseValue, "prerelease", "")
+	flags.StringVar(&prereleaseIdentifiers, "prerelease-identifiers", "", "")
 
 	flags.Var(
 		enumflag.New(&latest, "true", LatestIds, enumflag.EnumCaseInsensitive),
 		"latest",
 		"",
 	)
+	flags.StringVar(&latestTagPrefix, "latest-tag-prefix", "", "")
 
 	flags.IntVar(&parallel, "parallel", defaultParallel, "")
 	flags.IntVar(&parallel, "p", defaultParallel, "")
 
+	flags.BoolVar(&recursive, "recursive", false, "")
+	flags.Var(&include, "include", "")
+	flags.Var(&exclude, "exclude", "")
+
+	flags.BoolVar(&checksum, "checksum", false, "")
+	flags.StringVar(&checksumFile, "checksum-file", "", "")
+	flags.Var(
+		enumflag.New(&checksumAlgorithm, "sha256", ChecksumAlgorithmIds, enumflag.EnumCaseInsensitive),
+		"checksum-algorithm",
+		"",
+	)
+
 	flags.BoolVar(&recreate, "delete", false, "")
 	flags.BoolVar(&recreate, "recreate", false, "")
 
+	flags.BoolVar(&update, "update", false, "")
 	flags.BoolVar(&replace, "replace", false, "")
+	flags.BoolVar(&skipExisting, "skip-existing", false, "")
 
 	flags.BoolVar(&soft, "soft", false, "")
 
+	flags.BoolVar(&keepPartial, "keep-partial", false, "")
+	flags.BoolVar(&keepGoing, "keep-going", false, "")
+
+	flags.BoolVar(&dryRun, "dry-run", false, "")
+	flags.Var(
+		enumflag.New(&output, "text", OutputFormatIds, enumflag.EnumCaseInsensitive),
+		"output",
+		"",
+	)
+
 	flags.BoolVar(&version, "version", false, "")
 	flags.BoolVar(&version, "v", false, "")
 
@@ -186,6 +291,17 @@ func (cli *CLI) Run(args []string) int {
 		return ExitCodeOK
 	}
 
+	// Flags given on the command line. Env vars and config files set flags
+	// too, so they're recorded before them.
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	if code := repository.applyConfig(flags, cli.errStream); code != ExitCodeOK {
+		return code
+	}
+
 	parsedArgs := flags.Args()
 	Debugf("parsed args : %s", parsedArgs)
 	var tag, path string
@@ -200,81 +316,98 @@ func (cli *CLI) Run(args []string) int {
 		return ExitCodeBadArgs
 	}
 
-	// Extract github repository owner username.
-	// If it's not provided via command line flag, read it from .gitconfig
-	// (github user or git user).
-	if len(owner) == 0 {
-		origin, err := gitconfig.OriginURL()
-		if err == nil {
-			owner = retrieveOwnerName(origin)
+	// '-prerelease auto' is a bare '-prerelease' followed by TAG "auto".
+	if prereleaseValue == "true" && tag == string(prereleaseAuto) {
+		PrintRedf(cli.errStream,
+			"TAG is %q: use `-prerelease=auto` to decide prerelease by TAG, or `-prerelease=true` for the tag %q.\n",
+			tag, tag)
+		return ExitCodeBadArgs
+	}
+	if len(prereleaseIdentifiers) != 0 && prereleaseValue != prereleaseAuto {
+		fmt.Fprintln(cli.errStream, "WARNING: '-prerelease-identifiers' has no effect without '-prerelease=auto'.")
+	}
+
+	bodySources := 0
+	for _, s := range []string{body, bodyFile, changelog} {
+		if len(s) != 0 {
+			bodySources++
 		}
-		if len(owner) == 0 {
-			owner, err = gitconfig.GithubUser()
-			if err != nil {
-				owner, err = gitconfig.Username()
-			}
+	}
+	if bodySources > 1 {
+		PrintRedf(cli.errStream,
+			"Only one of `-body`, `-body-file` and `-body-from-changelog` can be set.\n")
+		return ExitCodeBadArgs
+	}
 
+	if update && recreate {
+		PrintRedf(cli.errStream,
+			"`-update` and `-recreate` can not be set at the same time.\n")
+		return ExitCodeBadArgs
+	}
+
+	if len(checksumFile) != 0 {
+		if !checksum {
+			PrintRedf(cli.errStream, "`-checksum-file` requires `-checksum`.\n")
+			return ExitCodeBadArgs
+		}
+		if err := ValidateChecksumFilename(checksumFile); err != nil {
+			PrintRedf(cli.errStream, "%s\n", err)
+			return ExitCodeBadArgs
+		}
+	}
+
+	// With -template, the name and the body are rendered as templates.
+	// Otherwise they're used as they are.
+	var nameTmpl, bodyTmpl *template.Template
+	if templated {
+		var err error
+		nameTmpl, err = ParseReleaseTemplate("name", name)
+		if err != nil {
+			PrintRedf(cli.errStream, "%s\n", err)
+			return ExitCodeBadArgs
+		}
+		if len(body) != 0 {
+			bodyTmpl, err = ParseReleaseTemplate("body", body)
 			if err != nil {
-				PrintRedf(cli.errStream,
-					"Failed to set up ghr: repository owner name not found\n")
-				fmt.Fprintf(cli.errStream,
-					"Please set it via `-u` option.\n\n"+
-						"You can set default owner name in `github.username` or `user.name`\n"+
-						"in `~/.gitconfig` file\n")
-				return ExitCodeOwnerNotFound
+				PrintRedf(cli.errStream, "%s\n", err)
+				return ExitCodeBadArgs
 			}
 		}
 	}
-	Debugf("Owner: %s", owner)
 
-	// Extract repository name from files.
-	// If not provided, read it from .git/config file.
-	if len(repo) == 0 {
+	if len(bodyFile) != 0 {
 		var err error
-		repo, err = gitconfig.Repository()
+		body, err = ReadBodyFile(bodyFile, cli.inStream)
 		if err != nil {
-			PrintRedf(cli.errStream,
-				"Failed to set up ghr: repository name not found\n")
-			fmt.Fprintf(cli.errStream,
-				"ghr reads it from `.git/config` file. Change directory to \n"+
-					"repository root directory or setup git repository.\n"+
-					"Or set it via `-r` option.\n")
-			return ExitCodeRepoNotFound
+			PrintRedf(cli.errStream, "Failed to read release body: %s\n", err)
+			return ExitCodeError
 		}
 	}
-	Debugf("Repository: %s", repo)
 
-	// If GitHub API token is not provided via command line flag
-	// or env var then read it from .gitconfig file.
-	if len(token) == 0 {
+	if len(changelog) != 0 {
 		var err error
-		token, err = gitconfig.GithubToken()
+		body, err = ReadChangelogSection(changelog, tag)
 		if err != nil {
-			PrintRedf(cli.errStream, "Failed to set up ghr: token not found\n")
-			fmt.Fprintf(cli.errStream,
-				"To use ghr, you need a GitHub API token.\n"+
-					"Please set it via `%s` env var or `-t` option.\n\n"+
-					"If you don't have one, visit official doc (goo.gl/jSnoI)\n"+
-					"and get it first.\n",
-				EnvGitHubToken)
-			return ExitCodeTokenNotFound
+			PrintRedf(cli.errStream, "Failed to read release body: %s\n", err)
+			return ExitCodeError
 		}
+		Debugf("Body from %s: %d bytes", changelog, len(body))
 	}
-	Debugf("Github API Token: %s", maskString(token))
 
-	// Set Base GitHub API URL. Base URL can also be provided via env var for use with GHE.
-	baseURLStr := defaultBaseURL
-	if urlStr := os.Getenv(EnvGitHubAPI); len(urlStr) != 0 {
-		baseURLStr = urlStr
+	if code := repository.resolve(cli.errStream); code != ExitCodeOK {
+		return code
 	}
-	Debugf("Base GitHub API URL: %s", baseURLStr)
 
 	if parallel <= 0 {
 		parallel = runtime.NumCPU()
 	}
 	Debugf("Parallel factor: %d", parallel)
 
-	localAssets, err := LocalAssets(path)
+	localAssets, err := LocalAssets(path, LocalAssetsOptions{
+		Recursive: recursive,
+		Include:   include,
+		Exclude:   exclude,
+	})
 	if err != nil {
 		PrintRedf(cli.errStream,
 			"Failed to find assets from %s: %s\n", path, err)
@@ -282,18 +415,113 @@ func (cli *CLI) Run(args []string) int {
 	}
 	Debugf("Number of file to upload: %d", len(localAssets))
 
+	// Check assets before creating anything on GitHub so that a bad asset
+	// does not leave a release with a partial set of assets behind.
+	if err := ValidateAssets(localAssets); err != nil {
+		PrintRedf(cli.errStream, "Invalid assets:\n%s\n", err)
+		return ExitCodeInvalidAssets
+	}
+
+	if checksum {
+		if len(checksumFile) == 0 {
+			checksumFile = checksumAlgorithm.DefaultFilename()
+		}
+		for _, localAsset := range localAssets {
+			if githubAssetName(filepath.Base(localAsset)) == githubAssetName(checksumFile) {
+				PrintRedf(cli.errStream,
+					"Invalid assets:\n%s: asset name conflicts with the checksums file\n", localAsset)
+				return ExitCodeInvalidAssets
+			}
+		}
+		Debugf("Checksums file: %s (%s)", checksumFile, checksumAlgorithm)
+	}
+
+	if prereleaseValue == prereleaseAuto {
+		var identifiers []string
+		for _, id := range strings.Split(prereleaseIdentifiers, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				identifiers = append(identifiers, id)
+			}
+		}
+		prerelease = IsPrereleaseTag(tag, latestTagPrefix, identifiers)
+	} else {
+		prerelease = prereleaseValue == "true"
+	}
+	Debugf("Prerelease: %t", prerelease)
+
 	Debugf("Set this release as latest: %s", latest)
 
+	// Cancel in-flight requests on SIGINT or SIGTERM.
+	ctx, cancel := cli.signalContext()
+	defer cancel()
+
 	// Create a GitHub client
-	gitHubClient, err := NewGitHubClient(owner, repo, token, baseURLStr)
+	gitHubClient, err := repository.newGitHubClient(ctx)
 	if err != nil {
 		PrintRedf(cli.errStream, "Failed to construct GitHub client: %s\n", err)
 		return ExitCodeError
 	}
 
+	// With JSON output, stdout is only for the JSON document. Progress
+	// messages go to stderr instead.
+	progressStream := cli.outStream
+	if output == outputJSON {
+		progressStream = cli.errStream
+	}
+
+	// In dry-run mode, only read-only requests are sent to GitHub and the
+	// others are printed as the plan.
+	if dryRun {
+		fmt.Fprintln(progressStream, "==> Dry run: nothing is changed on GitHub")
+		gitHubClient = &dryRunGitHub{
+			GitHub:    gitHubClient,
+			outStream: progressStream,
+		}
+	}
+
 	ghr := GHR{
 		GitHub:    gitHubClient,
-		outStream: cli.outStream,
+		outStream: progressStream,
+	}
+	if checksum {
+		ghr.ChecksumFile = checksumFile
+		ghr.ChecksumAlgorithm = checksumAlgorithm
+	}
+	ghr.SkipExisting = skipExisting
+	ghr.KeepGoing = keepGoing
+	ghr.NoTagWait = dryRun
+
+	// When ghr fails or is interrupted, roll back the objects created in
+	// this run so that the next run does not pick up a draft release with a
+	// partial set of assets.
+	completed := false
+	defer func() {
+		if completed || ghr.journal.empty() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultCleanupTimeout)
+		defer cancel()
+		if err := ghr.Abort(ctx, keepPartial); err != nil {
+			PrintRedf(cli.errStream, "Failed to roll back: %s\n", err)
+		}
+	}()
+
+	// Render the name and the body given inline. The body read from a file
+	// is used as it is.
+	templateData := NewReleaseTemplateData(ctx, ghr.GitHub, tag, latestTagPrefix, commitish,
+		repository.owner, repository.repo, localAssets, checksumAlgorithm)
+	if nameTmpl != nil {
+		if name, err = ExecuteReleaseTemplate(nameTmpl, templateData); err != nil {
+			PrintRedf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+	}
+	if bodyTmpl != nil {
+		if body, err = ExecuteReleaseTemplate(bodyTmpl, templateData); err != nil {
+			PrintRedf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
 	}
 
 	Debugf("Name: %s", name)
@@ -309,18 +537,10 @@ func (cli *CLI) Run(args []string) int {
 		GenerateReleaseNotes: github.Bool(generatenotes),
 	}
 
-	ctx := context.TODO()
-
 	if latest == setLatestAuto {
-		latestRelease, err := ghr.GitHub.GetLatestRelease(ctx)
+		isLatestRelease, err := ghr.IsLatestRelease(ctx, tag, latestTagPrefix, prerelease)
 		if err != nil {
-			fmt.Fprintf(cli.errStream, "Unable to fetch the latest release to compare versions: %s", err)
-			return ExitCodeError
-		}
-
-		isLatestRelease, err := ghr.IsNewerSemverRelease(req, latestRelease)
-		if err != nil {
-			fmt.Fprintf(cli.errStream, "Could not compare current and latest semver releases: %s", err)
+			PrintRedf(cli.errStream, "Could not decide whether the release is latest: %s\n", err)
 			return ExitCodeError
 		}
 		if isLatestRelease {
@@ -336,11 +556,41 @@ func (cli *CLI) Run(args []string) int {
 		}
 	}
 
+	// With -update, the fields given by flags on the command line are
+	// applied to the existing release. Defaults from env vars and config
+	// files do not overwrite it.
+	if update {
+		updateReq := &github.RepositoryRelease{}
+		if given["name"] || given["n"] {
+			updateReq.Name = req.Name
+		}
+		if given["body"] || given["b"] || given["body-file"] || given["body-from-changelog"] {
+			updateReq.Body = req.Body
+		}
+		if given["commitish"] || given["c"] {
+			updateReq.TargetCommitish = req.TargetCommitish
+		}
+		if given["prerelease"] {
+			updateReq.Prerelease = req.Prerelease
+		}
+		if given["latest"] {
+			updateReq.MakeLatest = req.MakeLatest
+			if updateReq.MakeLatest == nil {
+				updateReq.MakeLatest = github.String(strconv.FormatBool(latest == setLatestTrue))
+			}
+		}
+		ghr.Update = updateReq
+	}
+
 	if soft {
-		_, err := ghr.GitHub.GetRelease(ctx, *req.TagName)
+		release, err := ghr.GitHub.GetRelease(ctx, *req.TagName)
 
 		if err == nil {
-			fmt.Fprintf(cli.outStream, "ghr aborted since tag `%s` already exists\n", *req.TagName)
+			fmt.Fprintf(progressStream, "ghr aborted since tag `%s` already exists\n", *req.TagName)
+			// The existing release is the result of this run.
+			if output == outputJSON {
+				return cli.writeReleaseJSON(ctx, &ghr, release)
+			}
 			return ExitCodeOK
 		}
 
@@ -353,6 +603,11 @@ func (cli *CLI) Run(args []string) int {
 	release, err := ghr.GitHub.GetDraftRelease(ctx, tag)
 	if err != nil {
 		PrintRedf(cli.errStream, "Failed to get draft release: %s\n", err)
+		if errors.Is(err, ErrMultipleDraftReleases) {
+			fmt.Fprintf(cli.errStream,
+				"Delete the duplicated drafts (see `ghr list`) and run ghr again.\n")
+			return ExitCodeReleaseError
+		}
 		return ExitCodeError
 	}
 	if release == nil {
@@ -361,10 +616,22 @@ func (cli *CLI) Run(args []string) int {
 			PrintRedf(cli.errStream, "Failed to create GitHub release page: %s\n", err)
 			return ExitCodeError
 		}
+	} else if ghr.Update != nil {
+		release, err = ghr.UpdateRelease(ctx, release, ghr.Update)
+		if err != nil {
+			PrintRedf(cli.errStream, "Failed to update GitHub release: %s\n", err)
+			return ExitCodeError
+		}
 	}
 
-	if replace {
-		err := ghr.DeleteAssets(ctx, *release.ID, localAssets, parallel)
+	// With -skip-existing, only changed assets are replaced while uploading.
+	if replace && !skipExisting {
+		deleteAssets := localAssets
+		if checksum {
+			// The checksums file is uploaded again, so replace it too.
+			deleteAssets = append(deleteAssets[:len(deleteAssets):len(deleteAssets)], checksumFile)
+		}
+		err := ghr.DeleteAssets(ctx, *release.ID, deleteAssets, parallel)
 		if err != nil {
 			PrintRedf(cli.errStream, "Failed to delete existing assets: %s\n", err)
 			return ExitCodeError
@@ -380,12 +647,17 @@ func (cli *CLI) Run(args []string) int {
 
 	err = ghr.UploadAssets(ctx, *release.ID, localAssets, parallel)
 	if err != nil {
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			PrintRedf(cli.errStream, "Failed to upload assets: %s\n", err)
+			return ExitCodeUploadFailed
+		}
 		PrintRedf(cli.errStream, "Failed to upload one of assets: %s\n", err)
 		return ExitCodeError
 	}
 
 	if !draft {
-		_, err := ghr.GitHub.EditRelease(ctx, *release.ID, &github.RepositoryRelease{
+		release, err = ghr.GitHub.EditRelease(ctx, *release.ID, &github.RepositoryRelease{
 			Draft: github.Bool(false),
 		})
 		if err != nil {
@@ -394,17 +666,47 @@ func (cli *CLI) Run(args []string) int {
 		}
 	}
 
+	completed = true
+
+	if output == outputJSON {
+		return cli.writeReleaseJSON(ctx, &ghr, release)
+	}
+
 	return ExitCodeOK
 }
 
-var ownerNameReg = regexp.MustCompile(`([-a-zA-Z0-9]+)/[^/]+$`)
-
-func retrieveOwnerName(repoURL string) string {
-	matched := ownerNameReg.FindStringSubmatch(repoURL)
-	if len(matched) < 2 {
-		return ""
+// writeReleaseJSON writes the release and its assets as a JSON document to
+// the output stream and returns the exit code.
+func (cli *CLI) writeReleaseJSON(ctx context.Context, ghr *GHR, release *github.RepositoryRelease) int {
+	out, err := ghr.NewReleaseOutput(ctx, release)
+	if err != nil {
+		PrintRedf(cli.errStream, "Failed to get release: %s\n", err)
+		return ExitCodeError
 	}
-	return matched[1]
+	if err := WriteJSON(cli.outStream, out); err != nil {
+		PrintRedf(cli.errStream, "Failed to output release: %s\n", err)
+		return ExitCodeError
+	}
+	return ExitCodeOK
+}
+
+// signalContext returns a context which is canceled on SIGINT or SIGTERM. A
+// second signal terminates ghr immediately.
+func (cli *CLI) signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigCh:
+			signal.Stop(sigCh)
+			PrintRedf(cli.errStream, "Received %s: canceling...\n", sig)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(sigCh)
+		}
+	}()
+	return ctx, cancel
 }
 
 // maskString is used to mask a string which should not be displayed
@@ -424,7 +726,10 @@ artifacts to it. ghr parallelizes upload of multiple artifacts.
 
 You must specify TAG (e.g., v1.0.0) and an optional PATH to local artifacts.
 If PATH is directory, ghr globs all files in the directory and
-upload it. If PATH is a file then, upload only it.
+upload it. If PATH is a file then, upload only it. Use '-recursive',
+'-include' and '-exclude' to control which files in the directory are
+uploaded. Before creating the release, ghr checks that assets are not
+empty, under 2 GiB and have unique names which GitHub does not rewrite.
 
 And you also must provide GitHub API token which has enough permission
 (For a private repository you need the 'repo' scope and for a public
@@ -432,21 +737,47 @@ repository need 'public_repo' scope). You can get token from GitHub's
 account setting page.
 
 You can use ghr on GitHub Enterprise. Set base URL via GITHUB_API
-environment variable.
+environment variable or '-api-url'.
 
-Options:
+Every option below can also be set by the env var shown next to it, e.g.,
+GHR_PARALLEL=4 for '-parallel=4'. Options which can be specified multiple
+times take comma separated values, e.g., GHR_INCLUDE='*.zip,*.tar.gz'.
+Write '\,' for a comma in a value. Patterns do not support braces like
+'*.{zip,tar.gz}', so list them separately.
+
+Config files:
+
+  ghr reads the default values of flags from .ghr.yaml (or .ghr.toml) in
+  the repository root and $XDG_CONFIG_HOME/ghr/config (~/.config/ghr/config,
+  in YAML or TOML with the .toml extension). Keys are the long names of
+  flags: owner, repository, api-url, remote, app-id, app-private-key,
+  installation-id, parallel, recursive, include, exclude, checksum,
+  checksum-file, checksum-algorithm, name, body, template, draft,
+  prerelease, prerelease-identifiers, latest, latest-tag-prefix, commitish,
+  skip-existing, keep-going, generatenotes, retry-max-attempts and
+  retry-max-wait. api-url can be set only in the user config, so that a
+  repository config does not send the token to another host. Values under
+  'profiles.NAME' are used with '-profile NAME' over the others of the
+  same file. Flags take precedence over env vars, env vars over the
+  repository config, and the repository config over the user config.
 
--username, -owner, -u
-	Github repository owner name. By default, ghr extracts it from global
-	gitconfig value.
+Commands:
 
--repository, -r
-	GitHub repository name. By default, ghr extracts repository name from
-	current directory's .git/config.
+  ghr download [options...] TAG [DIR]
+	Download the assets of a release. See 'ghr download -h'.
 
--token, -t
-	GitHub API Token. By default, ghr reads it from 'GITHUB_TOKEN' env var.
+  ghr list [options...]
+	List the releases. See 'ghr list -h'.
 
+  ghr show [options...] TAG
+	Show a release and its assets. See 'ghr show -h'.
+
+  ghr prune [options...]
+	Delete old releases by a retention policy. See 'ghr prune -h'.
+
+Options:
+
+` + repositoryHelpText + `
 -commitish, -c
 	Set target commitish, branch or commit SHA
 
@@ -454,36 +785,155 @@ Options:
 	GitHub release title. By default the tag is used.
 
 -body, -b
-	Set text describing the contents of the release
+	Set text describing the contents of the release.
+
+-template
+	Render '-name' and '-body' as Go templates (text/template), which can
+	refer to the following variables:
+
+	  .Tag                   Tag of the release, e.g., v1.2.0-rc.1
+	  .Version               Tag without '-latest-tag-prefix' and the
+	                         leading 'v', e.g., 1.2.0-rc.1
+	  .Major .Minor .Patch   Parts of the version when it's a semver
+	  .Prerelease            Prerelease part of the version, e.g., rc.1
+	  .Commitish .Owner .Repo
+	  .Date                  Date of the release, e.g., 2026-10-18
+	  .PreviousTag           Tag of the highest release lower than the
+	                         version (skipping prereleases for a stable
+	                         one), or of the latest release when the tag
+	                         is not a semver
+	  .Assets                Artifacts with .Name, .Size and .Checksum
+	                         (by '-checksum-algorithm')
+
+	e.g., -template -name 'MyTool {{.Version}} ({{.Date}})'
+
+-body-file=PATH
+	Read the text describing the contents of the release from PATH. '-'
+	means stdin.
+
+-body-from-changelog=PATH
+	Use the section of the changelog in Markdown at PATH whose heading is
+	TAG as the text describing the contents of the release. Headings in
+	the style of Keep a Changelog ('## [1.0.0] - 2017-06-20') and tagpr
+	('## [v1.0.0](URL) - 2017-06-20') are supported, and the leading 'v'
+	of versions is ignored. ghr fails when no section is found
+	or the section is empty.
 
 -draft
 	Release as draft (Unpublish)
 
 -latest
 	Set the release as the 'latest' release. Can be true, false, or auto.
-	Auto will set the release as 'latest' if the release names are valid
-	semver names and the current release is the highest version of recent
-	releases.
+	Auto will set the release as 'latest' if its tag is a semver higher
+	than every published stable release, or if it's the first one. A
+	prerelease (by '-prerelease' or a version like v1.2.0-rc.1) never
+	becomes 'latest'. Tags which are not semver are ignored.
+
+-latest-tag-prefix=PREFIX
+	Prefix of tags to compare with '-latest auto', e.g., 'cli/' for tags
+	like cli/v1.2.3 in a monorepo. Releases whose tag does not have the
+	prefix are ignored. The version of TAG for '-prerelease=auto' and
+	'-template' is also the one without the prefix.
 
 -prerelease
-	Create prerelease
+	Create prerelease. With '-prerelease=auto', the release is created as
+	a prerelease when TAG is a semver with a prerelease version, e.g.,
+	v2.0.0-rc.1, after removing '-latest-tag-prefix' (e.g., cli/ for
+	cli/v2.0.0-rc.1). Note that '-prerelease auto' (with a space) means
+	'-prerelease' with TAG "auto", which ghr rejects.
+
+-prerelease-identifiers=alpha,beta,rc
+	Comma separated prerelease identifiers which make a prerelease with
+	'-prerelease=auto', e.g., rc for v2.0.0-rc.1 and v2.0.0-rc1. By
+	default, any prerelease version does. It has no effect without
+	'-prerelease=auto'.
 
 -parallel=-1
 	Parallelization factor. This option limits amount of parallelism of
-	uploading. By default, ghr uses number of logic CPU.
+	uploading. By default, ghr uses number of logic CPU. While uploading,
+	ghr shows the progress of each asset and the total on a terminal, or
+	prints it every 10 seconds otherwise.
+
+-recursive
+	Walk into subdirectories of PATH. Assets are still named after the
+	base name of each file, so two files with the same base name are an
+	error.
+
+-include=PATTERN
+	Upload only files matching PATTERN. Can be specified multiple times.
+	PATTERN is matched against the path relative to PATH and '**' matches
+	any number of directories (e.g., 'linux/**/*.tar.gz'). A PATTERN
+	without '/' is matched against the file name at any depth. Hidden
+	files and directories are skipped unless a segment of PATTERN for
+	them starts with '.' (e.g., '.config/*'); '*' and '**' do not match
+	them.
+
+-exclude=PATTERN
+	Skip files matching PATTERN. Can be specified multiple times and takes
+	precedence over '-include'.
+
+-checksum
+	Compute checksums of the artifacts and upload them as a checksums file
+	(e.g., SHA256SUMS) in the format of sha256sum(1) along with them.
+
+-checksum-algorithm=sha256
+	Hash algorithm of '-checksum'. Can be sha256 or sha512.
+
+-checksum-file=NAME
+	Asset name of the checksums file. By default, SHA256SUMS or SHA512SUMS
+	depending on '-checksum-algorithm'. It must be a file name
+	without path separators which GitHub keeps as is, and requires
+	'-checksum'.
 
 -delete, -recreate
 	Recreate release if it already exists. If want to upload to same release
 	and replace use '-replace'.
 
+-update
+	Apply '-name', '-body' (or '-body-file' and '-body-from-changelog'),
+	'-commitish', '-prerelease' and '-latest' given explicitly on the
+	command line (not by env vars or config files) to the existing release,
+	and print what changes. Without it, the existing
+	release is used as it is. Unlike '-recreate', the release keeps its
+	assets and their download counts.
+
 -replace
 	Replace artifacts if it is already uploaded. ghr thinks it's same when
 	local artifact base name and uploaded file name are same.
 
+-skip-existing
+	Skip uploading artifacts which are already uploaded with the same content
+	and replace the ones whose content differs. The content is compared by
+	size and the digest GitHub provides, or the uploaded checksums file
+	(SHA256SUMS, SHA512SUMS or '-checksum-file') when no digest exists.
+
 -soft
 	Stop uploading if the repository already has release with the specified
 	tag.
 
+-keep-going
+	Keep uploading the other artifacts when one of them fails, then print
+	the result of each artifact with its number of retries. ghr exits with
+	a distinct exit code when any upload failed. The uploaded artifacts are
+	kept as with '-keep-partial'.
+
+-keep-partial
+	Keep the objects created in this run when ghr fails or is interrupted
+	(SIGINT or SIGTERM). By default, ghr rolls back them: the release created
+	in this run is deleted, or the assets uploaded to the existing release
+	in this run are deleted except the ones replacing deleted assets.
+
+-dry-run
+	Print the plan of creating, editing and deleting releases, tags and
+	assets without changing anything. Existing releases and assets are still
+	read from GitHub, so a token is required.
+
+-output=text
+	Output format. Can be text or json. With json, ghr prints the release
+	and its uploaded assets as a JSON document to stdout and progress
+	messages to stderr. When '-soft' stops ghr, the existing release is
+	printed.
+
 -version, -v
 	Print ghr version and exit
 
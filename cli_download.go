@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// runDownload runs `ghr download`, which downloads the assets of a release.
+func (cli *CLI) runDownload(args []string) int {
+	var (
+		repository repositoryFlags
+		patterns   stringsFlag
+		parallel   int
+	)
+
+	flags := repository.newFlagSet("download", downloadHelpText, cli.errStream)
+	flags.Var(&patterns, "pattern", "")
+
+	flags.IntVar(&parallel, "parallel", defaultParallel, "")
+	flags.IntVar(&parallel, "p", defaultParallel, "")
+
+	if code := repository.parse(flags, args, cli.errStream); code != ExitCodeOK {
+		return code
+	}
+
+	parsedArgs := flags.Args()
+	var tag, dir string
+	switch len(parsedArgs) {
+	case 1:
+		tag, dir = parsedArgs[0], "."
+	case 2:
+		tag, dir = parsedArgs[0], parsedArgs[1]
+	default:
+		PrintRedf(cli.errStream,
+			"Invalid number of arguments: you must set a git TAG and optionally a DIR.\n")
+		return ExitCodeBadArgs
+	}
+
+	for _, pattern := range patterns {
+		if err := validatePattern(pattern); err != nil {
+			PrintRedf(cli.errStream, "%s\n", err)
+			return ExitCodeBadArgs
+		}
+	}
+
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+	Debugf("Parallel factor: %d", parallel)
+
+	// Cancel in-flight requests on SIGINT or SIGTERM. Partial files are
+	// kept to resume from in the next run.
+	ctx, cancel := cli.signalContext()
+	defer cancel()
+
+	gitHubClient, code := repository.newClient(ctx, cli.errStream)
+	if code != ExitCodeOK {
+		return code
+	}
+
+	ghr := GHR{
+		GitHub:    gitHubClient,
+		outStream: cli.outStream,
+	}
+
+	release, err := ghr.FindRelease(ctx, tag)
+	if err != nil {
+		if errors.Is(err, ErrReleaseNotFound) {
+			PrintRedf(cli.errStream, "Release (%s) not found\n", tag)
+			return ExitCodeReleaseError
+		}
+		PrintRedf(cli.errStream, "%s\n", err)
+		return ExitCodeError
+	}
+
+	fmt.Fprintf(cli.outStream, "==> Download assets of release %s\n", tag)
+	if err := ghr.DownloadAssets(ctx, release.GetID(), dir, patterns, parallel); err != nil {
+		PrintRedf(cli.errStream, "Failed to download assets: %s\n", err)
+		return ExitCodeError
+	}
+
+	return ExitCodeOK
+}
+
+var downloadHelpText = `Usage: ghr download [options...] TAG [DIR]
+
+Download the assets of the release of TAG into DIR (by default, the
+current directory).
+
+Each asset is written to NAME.part first and renamed after it's verified,
+so an interrupted download is resumed in the next run. Assets which are
+already downloaded with the same content are skipped. Downloaded assets are
+verified against their digest on GitHub or the checksums file uploaded
+with the release (SHA256SUMS or SHA512SUMS), if any.
+
+Options:
+
+` + repositoryHelpText + `
+-pattern=PATTERN
+	Download only assets whose names match PATTERN (e.g., '*.tar.gz').
+	Can be specified multiple times.
+
+-parallel=-1
+	Parallelization factor. This option limits amount of parallelism of
+	downloading. By default, ghr uses number of logic CPU.
+
+-debug
+	Enable debug output
+
+The base URL of the GitHub API can be set by the GITHUB_API env var for
+GitHub Enterprise, as with ghr.
+`
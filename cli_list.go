@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/thediveo/enumflag/v2"
+)
+
+// runList runs `ghr list`, which lists the releases of the repository.
+func (cli *CLI) runList(args []string) int {
+	var (
+		repository repositoryFlags
+		output     OutputFormat
+	)
+
+	flags := repository.newFlagSet("list", listHelpText, cli.errStream)
+	flags.Var(
+		enumflag.New(&output, "text", OutputFormatIds, enumflag.EnumCaseInsensitive),
+		"output",
+		"",
+	)
+
+	if code := repository.parse(flags, args, cli.errStream); code != ExitCodeOK {
+		return code
+	}
+
+	if flags.NArg() != 0 {
+		PrintRedf(cli.errStream, "Invalid number of arguments: list takes no arguments.\n")
+		return ExitCodeBadArgs
+	}
+
+	ctx, cancel := cli.signalContext()
+	defer cancel()
+
+	gitHubClient, code := repository.newClient(ctx, cli.errStream)
+	if code != ExitCodeOK {
+		return code
+	}
+	ghr := GHR{GitHub: gitHubClient, outStream: cli.outStream}
+
+	releases, err := ghr.ReleaseOutputs(ctx)
+	if err != nil {
+		PrintRedf(cli.errStream, "Failed to list releases: %s\n", err)
+		return ExitCodeError
+	}
+
+	if output == outputJSON {
+		err = WriteJSON(cli.outStream, releases)
+	} else {
+		err = WriteReleaseTable(cli.outStream, releases)
+	}
+	if err != nil {
+		PrintRedf(cli.errStream, "Failed to output releases: %s\n", err)
+		return ExitCodeError
+	}
+
+	return ExitCodeOK
+}
+
+// runShow runs `ghr show`, which shows a release and its assets.
+func (cli *CLI) runShow(args []string) int {
+	var (
+		repository repositoryFlags
+		output     OutputFormat
+	)
+
+	flags := repository.newFlagSet("show", showHelpText, cli.errStream)
+	flags.Var(
+		enumflag.New(&output, "text", OutputFormatIds, enumflag.EnumCaseInsensitive),
+		"output",
+		"",
+	)
+
+	if code := repository.parse(flags, args, cli.errStream); code != ExitCodeOK {
+		return code
+	}
+
+	if flags.NArg() != 1 {
+		PrintRedf(cli.errStream, "Invalid number of arguments: you must set a git TAG.\n")
+		return ExitCodeBadArgs
+	}
+	tag := flags.Arg(0)
+
+	ctx, cancel := cli.signalContext()
+	defer cancel()
+
+	gitHubClient, code := repository.newClient(ctx, cli.errStream)
+	if code != ExitCodeOK {
+		return code
+	}
+	ghr := GHR{GitHub: gitHubClient, outStream: cli.outStream}
+
+	release, err := ghr.FindRelease(ctx, tag)
+	if err != nil {
+		if errors.Is(err, ErrReleaseNotFound) {
+			PrintRedf(cli.errStream, "Release (%s) not found\n", tag)
+			return ExitCodeReleaseError
+		}
+		PrintRedf(cli.errStream, "%s\n", err)
+		return ExitCodeError
+	}
+
+	out, err := ghr.NewReleaseOutput(ctx, release)
+	if err != nil {
+		PrintRedf(cli.errStream, "Failed to get release: %s\n", err)
+		return ExitCodeError
+	}
+
+	if output == outputJSON {
+		err = WriteJSON(cli.outStream, out)
+	} else {
+		err = WriteRelease(cli.outStream, out)
+	}
+	if err != nil {
+		PrintRedf(cli.errStream, "Failed to output release: %s\n", err)
+		return ExitCodeError
+	}
+
+	return ExitCodeOK
+}
+
+var listHelpText = `Usage: ghr list [options...]
+
+List all releases of the repository including drafts, newest first, with
+their status (draft, prerelease, latest or published), number of assets
+and published date.
+
+Options:
+
+` + repositoryHelpText + `
+-output=text
+	Output format. Can be text (a table) or json.
+
+-debug
+	Enable debug output
+`
+
+var showHelpText = `Usage: ghr show [options...] TAG
+
+Show the release of TAG (or the draft release which will have TAG) and its
+assets with sizes, digests and download counts.
+
+Options:
+
+` + repositoryHelpText + `
+-output=text
+	Output format. Can be text or json.
+
+-debug
+	Enable debug output
+`
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultPruneKinds     = "release,prerelease"
+	defaultPruneMaxDelete = 10
+)
+
+// runPrune runs `ghr prune`, which deletes old releases by a retention
+// policy.
+func (cli *CLI) runPrune(args []string) int {
+	var (
+		repository repositoryFlags
+		keep       int
+		olderThan  string
+		kinds      string
+		tag        string
+		tagRegexp  string
+		keepTag    bool
+		maxDelete  int
+		dryRun     bool
+	)
+
+	flags := repository.newFlagSet("prune", pruneHelpText, cli.errStream)
+	flags.IntVar(&keep, "keep", -1, "")
+	flags.StringVar(&olderThan, "older-than", "", "")
+	flags.StringVar(&kinds, "kind", defaultPruneKinds, "")
+	flags.StringVar(&tag, "tag", "", "")
+	flags.StringVar(&tagRegexp, "tag-regexp", "", "")
+	flags.BoolVar(&keepTag, "keep-tag", false, "")
+	flags.IntVar(&maxDelete, "max-delete", defaultPruneMaxDelete, "")
+	flags.BoolVar(&dryRun, "dry-run", false, "")
+
+	if code := repository.parse(flags, args, cli.errStream); code != ExitCodeOK {
+		return code
+	}
+
+	if flags.NArg() != 0 {
+		PrintRedf(cli.errStream, "Invalid number of arguments: prune takes no arguments.\n")
+		return ExitCodeBadArgs
+	}
+
+	policy := &PrunePolicy{
+		Keep:       keep,
+		TagPattern: tag,
+		KeepTag:    keepTag,
+		MaxDelete:  maxDelete,
+	}
+	for _, kind := range strings.Split(kinds, ",") {
+		policy.Kinds = append(policy.Kinds, strings.TrimSpace(kind))
+	}
+	if olderThan != "" {
+		age, err := ParseAge(olderThan)
+		if err != nil {
+			PrintRedf(cli.errStream, "Invalid prune policy: %s\n", err)
+			return ExitCodeBadArgs
+		}
+		policy.OlderThan = age
+	}
+	if tagRegexp != "" {
+		re, err := regexp.Compile(tagRegexp)
+		if err != nil {
+			PrintRedf(cli.errStream, "Invalid prune policy: invalid tag regexp: %s\n", err)
+			return ExitCodeBadArgs
+		}
+		policy.TagRegexp = re
+	}
+	if err := policy.Validate(); err != nil {
+		PrintRedf(cli.errStream, "Invalid prune policy: %s\n", err)
+		return ExitCodeBadArgs
+	}
+	Debugf("Prune policy: %+v", policy)
+
+	ctx, cancel := cli.signalContext()
+	defer cancel()
+
+	gitHubClient, code := repository.newClient(ctx, cli.errStream)
+	if code != ExitCodeOK {
+		return code
+	}
+
+	if dryRun {
+		fmt.Fprintln(cli.outStream, "==> Dry run: nothing is changed on GitHub")
+		gitHubClient = &dryRunGitHub{
+			GitHub:    gitHubClient,
+			outStream: cli.outStream,
+		}
+	}
+
+	ghr := GHR{GitHub: gitHubClient, outStream: cli.outStream}
+
+	if _, err := ghr.Prune(ctx, policy); err != nil {
+		PrintRedf(cli.errStream, "Failed to prune releases: %s\n", err)
+		return ExitCodeError
+	}
+
+	return ExitCodeOK
+}
+
+var pruneHelpText = `Usage: ghr prune [options...]
+
+Delete old releases by a retention policy. A release is deleted only when
+it matches '-kind', '-tag' and '-tag-regexp' and it's beyond both '-keep'
+and '-older-than' (when set). At least one of '-keep' and '-older-than'
+must be set. The latest release is never deleted.
+
+Options:
+
+` + repositoryHelpText + `
+-keep=N
+	Keep the newest N releases of each kind. Releases of each kind are
+	counted separately, so nightly prereleases do not push out stable
+	releases.
+
+-older-than=AGE
+	Delete only releases published (or created, for drafts) longer than AGE
+	ago, e.g., 72h or 30d.
+
+-kind=release,prerelease
+	Comma separated kinds of releases to prune: release, prerelease and
+	draft. Releases of other kinds are left as they are.
+
+-tag=PATTERN
+	Prune only releases whose tag matches the glob PATTERN, e.g.,
+	'nightly-*'.
+
+-tag-regexp=REGEXP
+	Prune only releases whose tag matches REGEXP.
+
+-keep-tag
+	Keep the git tags of the deleted releases.
+
+-max-delete=10
+	Maximum number of releases to delete at once. When more releases match
+	the policy, the oldest ones up to it are deleted and the number of the
+	remaining ones is printed; they're deleted by the next runs. 0 means no
+	limit.
+
+-dry-run
+	Print the releases which would be deleted without deleting them.
+
+-debug
+	Enable debug output
+`
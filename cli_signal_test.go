@@ -0,0 +1,33 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCLI_signalContext(t *testing.T) {
+	errStream := new(bytes.Buffer)
+	cli := &CLI{outStream: new(bytes.Buffer), errStream: errStream}
+
+	ctx, cancel := cli.signalContext()
+	defer cancel()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal("Kill failed:", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context is not canceled by SIGTERM")
+	}
+	if want := "Received terminated: canceling..."; !strings.Contains(errStream.String(), want) {
+		t.Fatalf("signalContext outputs %q, want %q", errStream.String(), want)
+	}
+}
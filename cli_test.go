@@ -3,10 +3,18 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/go-github/v66/github"
 )
 
 func TestRun(t *testing.T) {
@@ -106,6 +114,107 @@ func TestRun_recreate(t *testing.T) {
 	}
 }
 
+// testAPIServer starts a fake GitHub API server of tcnksm/ghr which serves
+// the release, and returns the flags for ghr to use it. Config files of the
+// user are not read.
+func testAPIServer(t *testing.T, mux *http.ServeMux, release *github.RepositoryRelease) string {
+	t.Helper()
+	t.Setenv(EnvXDGConfigHome, t.TempDir())
+
+	writeJSON := func(v any) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(v)
+		}
+	}
+	mux.HandleFunc("GET /repos/tcnksm/ghr/releases", writeJSON([]*github.RepositoryRelease{release}))
+	mux.HandleFunc("GET /repos/tcnksm/ghr/releases/tags/"+release.GetTagName(), writeJSON(release))
+	mux.HandleFunc("GET /repos/tcnksm/ghr/releases/latest", writeJSON(release))
+	mux.HandleFunc(fmt.Sprintf("GET /repos/tcnksm/ghr/releases/%d/assets", release.GetID()), writeJSON(release.Assets))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return fmt.Sprintf("-token token -api-url %s/ -owner tcnksm -repository ghr", server.URL)
+}
+
+func TestRun_softJSON(t *testing.T) {
+	release := &github.RepositoryRelease{
+		ID:      github.Int64(1),
+		TagName: github.String("v1.0.0"),
+		HTMLURL: github.String("https://github.com/tcnksm/ghr/releases/tag/v1.0.0"),
+		Assets:  []*github.ReleaseAsset{{ID: github.Int64(2), Name: github.String("darwin_386"), Size: github.Int(11)}},
+	}
+	flags := testAPIServer(t, http.NewServeMux(), release)
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &CLI{outStream: outStream, errStream: errStream}
+	command := fmt.Sprintf("ghr %s -soft -output json v1.0.0 %s", flags, TestDir)
+
+	if got, want := cli.Run(strings.Split(command, " ")), ExitCodeOK; got != want {
+		t.Fatalf("%q exits %d, want %d\n\n%s", command, got, want, errStream.String())
+	}
+
+	// The existing release is printed as the result.
+	var out ReleaseOutput
+	if err := json.Unmarshal(outStream.Bytes(), &out); err != nil {
+		t.Fatalf("%q outputs invalid JSON %q: %s", command, outStream.String(), err)
+	}
+	if out.ID != 1 || out.Tag != "v1.0.0" || !out.Latest || len(out.Assets) != 1 || out.Assets[0].Name != "darwin_386" {
+		t.Fatalf("%q outputs %+v", command, out)
+	}
+	if want := "ghr aborted since tag `v1.0.0` already exists"; !strings.Contains(errStream.String(), want) {
+		t.Fatalf("%q outputs %q to stderr, want %q", command, errStream.String(), want)
+	}
+}
+
+func TestRun_update(t *testing.T) {
+	release := &github.RepositoryRelease{
+		ID:              github.Int64(1),
+		TagName:         github.String("v1.0.0"),
+		Name:            github.String("Old name"),
+		Body:            github.String("Old body"),
+		TargetCommitish: github.String("main"),
+		Draft:           github.Bool(true),
+		UploadURL:       github.String("https://uploads.github.com/repos/tcnksm/ghr/releases/1/assets{?name,label}"),
+	}
+
+	var edits []map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /repos/tcnksm/ghr/releases/1", func(w http.ResponseWriter, r *http.Request) {
+		var edit map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+			t.Errorf("invalid request body: %s", err)
+		}
+		edits = append(edits, edit)
+		json.NewEncoder(w).Encode(release)
+	})
+	flags := testAPIServer(t, mux, release)
+
+	// Defaults from env vars and config files do not overwrite the release.
+	t.Setenv("GHR_BODY", "Body from env")
+	t.Setenv("GHR_COMMITISH", "develop")
+	configDir := t.TempDir()
+	t.Setenv(EnvXDGConfigHome, configDir)
+	if err := os.MkdirAll(filepath.Join(configDir, "ghr"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "ghr", "config"), []byte("prerelease: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &CLI{outStream: outStream, errStream: errStream}
+	command := fmt.Sprintf("ghr %s -update -draft -n New-name v1.0.0", flags)
+
+	if got, want := cli.Run(strings.Split(command, " ")), ExitCodeOK; got != want {
+		t.Fatalf("%q exits %d, want %d\n\n%s", command, got, want, errStream.String())
+	}
+
+	want := []map[string]any{{"name": "New-name"}}
+	if !reflect.DeepEqual(edits, want) {
+		t.Fatalf("%q edits the release with %v; want %v\n\n%s", command, edits, want, outStream.String())
+	}
+}
+
 func TestRun_versionFlag(t *testing.T) {
 	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
 	cli := &CLI{outStream: outStream, errStream: errStream}
@@ -123,22 +232,34 @@ func TestRun_versionFlag(t *testing.T) {
 	}
 }
 
-func TestRetrieveOwnerName(t *testing.T) {
-	testCases := []struct {
-		in, expected string
-	}{
-		{"git@github.com:tcnksm/ghr.git", "tcnksm"},
-		{"https://github.com/motemen/ghq.git", "motemen"},
-		{"git://github.com/Songmu/ghg.git", "Songmu"},
-		{"hoge", ""},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.in, func(t *testing.T) {
-			out := retrieveOwnerName(tc.in)
-			if out != tc.expected {
-				t.Errorf("out: %s, expected: %s", out, tc.expected)
+func TestRun_prereleaseAutoTag(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &CLI{outStream: outStream, errStream: errStream}
+	command := "ghr -prerelease auto " + TestDir
+	args := strings.Split(command, " ")
+
+	if got, want := cli.Run(args), ExitCodeBadArgs; got != want {
+		t.Fatalf("%q exits %d, want %d", command, got, want)
+	}
+	if want := "-prerelease=auto"; !strings.Contains(errStream.String(), want) {
+		t.Fatalf("%q output %q, want %q", command, errStream.String(), want)
+	}
+}
+
+func TestRun_subcommandHelp(t *testing.T) {
+	for _, command := range []string{"download", "list", "show", "prune"} {
+		outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+		cli := &CLI{outStream: outStream, errStream: errStream}
+
+		if got, want := cli.Run([]string{"ghr", command, "-h"}), ExitCodeParseFlagsError; got != want {
+			t.Fatalf("ghr %s -h exits %d, want %d", command, got, want)
+		}
+
+		// Every subcommand has the options of the repository.
+		for _, want := range []string{"-token, -t", "-profile=NAME", "-retry-max-attempts=3", "-retry-max-wait=1m", "-debug"} {
+			if got := errStream.String(); !strings.Contains(got, want) {
+				t.Errorf("ghr %s -h output lacks %q:\n%s", command, want, got)
 			}
-		})
+		}
 	}
 }
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvXDGConfigHome is an environment var containing the base directory of
+// user config files.
+const EnvXDGConfigHome = "XDG_CONFIG_HOME"
+
+// repoConfigNames are the names of the config file in the repository root.
+var repoConfigNames = []string{".ghr.yaml", ".ghr.yml", ".ghr.toml"}
+
+// configKeys are the flags which can be set in config files. Keys are the
+// long names of flags.
+var configKeys = map[string]bool{
+	"owner":                  true,
+	"repository":             true,
+	"api-url":                true,
+	"remote":                 true,
+	"app-id":                 true,
+	"app-private-key":        true,
+	"installation-id":        true,
+	"retry-max-attempts":     true,
+	"retry-max-wait":         true,
+	"commitish":              true,
+	"name":                   true,
+	"body":                   true,
+	"template":               true,
+	"draft":                  true,
+	"prerelease":             true,
+	"prerelease-identifiers": true,
+	"latest":                 true,
+	"latest-tag-prefix":      true,
+	"parallel":               true,
+	"recursive":              true,
+	"include":                true,
+	"exclude":                true,
+	"checksum":               true,
+	"checksum-file":          true,
+	"checksum-algorithm":     true,
+	"skip-existing":          true,
+	"keep-going":             true,
+	"generatenotes":          true,
+}
+
+// userConfigKeys are the keys which can be set only in the user config. A
+// repository config is written by anyone who can push to the repository, so
+// it must not send the token to another host.
+var userConfigKeys = map[string]bool{
+	"api-url": true,
+}
+
+// configConflicts are the flags which can not be set with a key in config
+// files or env vars. The key is ignored when one of them is set by a flag,
+// and vice versa.
+var configConflicts = map[string][]string{
+	"body": {"body-file", "body-from-changelog"},
+}
+
+// Config is a config file which gives the default values of flags, either
+// .ghr.yaml (or .ghr.toml) in the repository root or the user config file.
+type Config struct {
+	Path string
+
+	// Values are the values of flags by name. A flag which can be
+	// specified multiple times, like include, has multiple values.
+	Values map[string][]string
+
+	// Profiles are the named sets of values selected by -profile. They
+	// take precedence over Values of the same file.
+	Profiles map[string]map[string][]string
+}
+
+// LoadConfig reads the config file at path. It returns nil without error
+// when the file does not exist. Files are TOML when the extension is .toml
+// and YAML otherwise.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	config := &Config{Path: path, Profiles: make(map[string]map[string][]string)}
+	if profiles, ok := raw["profiles"]; ok {
+		delete(raw, "profiles")
+		m, ok := profiles.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: profiles must be a map of profile names", path)
+		}
+		for name, profile := range m {
+			p, ok := profile.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: profile %s must be a map", path, name)
+			}
+			if config.Profiles[name], err = configValues(p); err != nil {
+				return nil, fmt.Errorf("%s: profile %s: %w", path, name, err)
+			}
+		}
+	}
+	if config.Values, err = configValues(raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// configValues converts the values decoded from a config file into the
+// values of flags.
+func configValues(raw map[string]interface{}) (map[string][]string, error) {
+	values := make(map[string][]string, len(raw))
+	for key, v := range raw {
+		if !configKeys[key] {
+			return nil, fmt.Errorf("unknown key %q", key)
+		}
+
+		vs, ok := v.([]interface{})
+		if !ok {
+			vs = []interface{}{v}
+		}
+		for _, v := range vs {
+			s, err := configValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %w", key, err)
+			}
+			values[key] = append(values[key], s)
+		}
+	}
+	return values, nil
+}
+
+func configValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// values returns the values of the profile overlaid on the ones of the
+// file, and whether the file has the profile.
+func (c *Config) values(profile string) (map[string][]string, bool) {
+	if profile == "" {
+		return c.Values, false
+	}
+	p, ok := c.Profiles[profile]
+	if !ok {
+		return c.Values, false
+	}
+
+	values := make(map[string][]string, len(c.Values)+len(p))
+	for key, v := range c.Values {
+		values[key] = v
+	}
+	for key, v := range p {
+		values[key] = v
+	}
+	return values, true
+}
+
+// ApplyConfig sets the flags which are not set on the command line or by env
+// vars (see ApplyEnv) from configs. Configs are in order of precedence, e.g.,
+// the repository config and then the user config. Only the flags defined in
+// flags are set, so subcommands take the keys which they know.
+func ApplyConfig(flags *flag.FlagSet, profile string, configs ...*Config) error {
+	// Aliases like -owner and -u share the same value.
+	set := make(map[flag.Value]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Value] = true
+	})
+
+	found := profile == ""
+	for _, config := range configs {
+		if config == nil {
+			continue
+		}
+		values, ok := config.values(profile)
+		found = found || ok
+
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var applied []flag.Value
+		for _, name := range names {
+			f := flags.Lookup(name)
+			if f == nil || set[f.Value] || conflicted(flags, set, name) {
+				continue
+			}
+			for _, v := range values[name] {
+				if err := flags.Set(name, v); err != nil {
+					return fmt.Errorf("%s: invalid value of %s: %w", config.Path, name, err)
+				}
+			}
+			v := strings.Join(values[name], ",")
+			if secretFlags[name] {
+				v = maskString(v)
+			}
+			Debugf("Config: %s = %s (%s)", name, v, config.Path)
+			applied = append(applied, f.Value)
+		}
+
+		// Configs of lower precedence do not add values to the flags set
+		// by this one, e.g., include.
+		for _, v := range applied {
+			set[v] = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("profile %q not found", profile)
+	}
+	return nil
+}
+
+// conflicted reports whether one of the flags which conflict with the flag
+// name is already set.
+func conflicted(flags *flag.FlagSet, set map[flag.Value]bool, name string) bool {
+	others := configConflicts[name]
+	for key, conflicts := range configConflicts {
+		for _, c := range conflicts {
+			if c == name {
+				others = append(others[:len(others):len(others)], key)
+			}
+		}
+	}
+
+	for _, other := range others {
+		if f := flags.Lookup(other); f != nil && set[f.Value] {
+			return true
+		}
+	}
+	return false
+}
+
+// RepoConfigPath returns the path of the config file in the root of the
+// repository which contains the current directory, or "" if there is none.
+// The root is the current directory outside of git repositories.
+func RepoConfigPath() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	root := wd
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			root = dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	var paths []string
+	for _, name := range repoConfigNames {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	switch len(paths) {
+	case 0:
+		return "", nil
+	case 1:
+		return paths[0], nil
+	default:
+		return "", fmt.Errorf("multiple config files: %s", strings.Join(paths, ", "))
+	}
+}
+
+// UserConfigPath returns the path of the user config file,
+// $XDG_CONFIG_HOME/ghr/config (~/.config/ghr/config by default) in YAML or
+// the same path with the extension .yaml or .toml. It returns "" if there
+// is none.
+func UserConfigPath() (string, error) {
+	dir := os.Getenv(EnvXDGConfigHome)
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	for _, name := range []string{"config", "config.yaml", "config.yml", "config.toml"} {
+		path := filepath.Join(dir, Name, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// checkRepoConfig checks that the repository config has no keys which can be
+// set only in the user config.
+func checkRepoConfig(config *Config) error {
+	check := func(values map[string][]string) error {
+		for key := range values {
+			if userConfigKeys[key] {
+				return fmt.Errorf("%s: %s can be set only in the user config", config.Path, key)
+			}
+		}
+		return nil
+	}
+
+	if err := check(config.Values); err != nil {
+		return err
+	}
+	for _, profile := range config.Profiles {
+		if err := check(profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadConfigs loads the repository config and the user config, in order of
+// precedence.
+func LoadConfigs() ([]*Config, error) {
+	var configs []*Config
+	for i, find := range []func() (string, error){RepoConfigPath, UserConfigPath} {
+		path, err := find()
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			continue
+		}
+		config, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 && config != nil {
+			if err := checkRepoConfig(config); err != nil {
+				return nil, err
+			}
+		}
+		Debugf("Config file: %s", path)
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	want := &Config{
+		Values: map[string][]string{
+			"owner":    {"tcnksm"},
+			"parallel": {"4"},
+			"include":  {"*.tar.gz", "*.zip"},
+			"checksum": {"true"},
+			"name":     {"ghr {{.Version}}"},
+		},
+		Profiles: map[string]map[string][]string{
+			"nightly": {
+				"prerelease": {"true"},
+				"latest":     {"false"},
+			},
+		},
+	}
+
+	files := map[string]string{
+		".ghr.yaml": `owner: tcnksm
+parallel: 4
+include:
+  - "*.tar.gz"
+  - "*.zip"
+checksum: true
+name: "ghr {{.Version}}"
+profiles:
+  nightly:
+    prerelease: true
+    latest: "false"
+`,
+		".ghr.toml": `owner = "tcnksm"
+parallel = 4
+include = ["*.tar.gz", "*.zip"]
+checksum = true
+name = "ghr {{.Version}}"
+
+[profiles.nightly]
+prerelease = true
+latest = "false"
+`,
+	}
+
+	for name, content := range files {
+		path := writeConfig(t, name, content)
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: LoadConfig failed: %s", name, err)
+		}
+		want.Path = path
+		if !reflect.DeepEqual(config, want) {
+			t.Fatalf("%s: LoadConfig = %#v; want %#v", name, config, want)
+		}
+	}
+
+	config, err := LoadConfig(filepath.Join(t.TempDir(), ".ghr.yaml"))
+	if err != nil || config != nil {
+		t.Fatalf("LoadConfig of a missing file = %v, %v; want nil", config, err)
+	}
+
+	for _, content := range []string{
+		"token: secret\n",
+		"profiles:\n  nightly:\n    tokens: secret\n",
+		"include: [{a: b}]\n",
+	} {
+		if _, err := LoadConfig(writeConfig(t, ".ghr.yaml", content)); err == nil {
+			t.Fatalf("expect LoadConfig to fail: %q", content)
+		}
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	t.Setenv(EnvGitHubAPI, "https://github.example.com/api/v3/")
+
+	repoConfig := &Config{
+		Path: ".ghr.yaml",
+		Values: map[string][]string{
+			"owner":   {"repo-owner"},
+			"api-url": {"https://repo.example.com/api/v3/"},
+			"include": {"*.zip"},
+			"body":    {"repo body"},
+		},
+		Profiles: map[string]map[string][]string{
+			"nightly": {"parallel": {"2"}},
+		},
+	}
+	userConfig := &Config{
+		Path: "config",
+		Values: map[string][]string{
+			"owner":      {"user-owner"},
+			"repository": {"user-repo"},
+			"include":    {"*.tar.gz"},
+			"parallel":   {"8"},
+			"latest":     {"auto"},
+		},
+		Profiles: map[string]map[string][]string{
+			"stable": {"latest": {"true"}},
+		},
+	}
+
+	newFlags := func() (*flag.FlagSet, *repositoryFlags, *stringsFlag, *int, *string, *string) {
+		var (
+			repository repositoryFlags
+			include    stringsFlag
+			parallel   int
+			latest     string
+			body       string
+			bodyFile   string
+		)
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		repository.register(flags)
+		flags.Var(&include, "include", "")
+		flags.IntVar(&parallel, "parallel", -1, "")
+		flags.IntVar(&parallel, "p", -1, "")
+		flags.StringVar(&latest, "latest", "", "")
+		flags.StringVar(&body, "body", "", "")
+		flags.StringVar(&bodyFile, "body-file", "", "")
+		return flags, &repository, &include, &parallel, &latest, &body
+	}
+
+	// Repository config takes precedence over user config, and env vars
+	// over both.
+	flags, repository, include, parallel, latest, body := newFlags()
+	if err := flags.Parse([]string{"-u", "flag-owner", "-body-file", "NOTES.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyEnv(flags); err != nil {
+		t.Fatalf("ApplyEnv failed: %s", err)
+	}
+	if err := ApplyConfig(flags, "", repoConfig, userConfig); err != nil {
+		t.Fatalf("ApplyConfig failed: %s", err)
+	}
+	if repository.owner != "flag-owner" {
+		t.Fatalf("owner = %q; want the flag", repository.owner)
+	}
+	if repository.repo != "user-repo" {
+		t.Fatalf("repo = %q; want the user config", repository.repo)
+	}
+	if repository.apiURL != "https://github.example.com/api/v3/" {
+		t.Fatalf("api-url = %q; want the env var", repository.apiURL)
+	}
+	if !reflect.DeepEqual(*include, stringsFlag{"*.zip"}) {
+		t.Fatalf("include = %q; want the repository config only", *include)
+	}
+	if *parallel != 8 || *latest != "auto" {
+		t.Fatalf("parallel = %d, latest = %q; want the user config", *parallel, *latest)
+	}
+	if *body != "" {
+		t.Fatalf("body = %q; want it to be ignored with -body-file", *body)
+	}
+
+	// Profiles take precedence over the values of the same file.
+	flags, _, _, parallel, latest, _ = newFlags()
+	if err := flags.Parse([]string{"-profile", "nightly"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyConfig(flags, "nightly", repoConfig, userConfig); err != nil {
+		t.Fatalf("ApplyConfig failed: %s", err)
+	}
+	if *parallel != 2 || *latest != "auto" {
+		t.Fatalf("parallel = %d, latest = %q; want 2 and auto", *parallel, *latest)
+	}
+
+	flags, _, _, _, _, _ = newFlags()
+	if err := ApplyConfig(flags, "weekly", repoConfig, userConfig); err == nil {
+		t.Fatal("expect ApplyConfig to fail with an unknown profile")
+	}
+}
+
+func TestRepoConfigPath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "cmd", "ghr")
+	for _, d := range []string{filepath.Join(root, ".git"), dir} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	if path, err := RepoConfigPath(); err != nil || path != "" {
+		t.Fatalf("RepoConfigPath = %q, %v; want none", path, err)
+	}
+
+	want := filepath.Join(root, ".ghr.toml")
+	if err := os.WriteFile(want, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := RepoConfigPath(); err != nil || path != want {
+		t.Fatalf("RepoConfigPath = %q, %v; want %q", path, err, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, ".ghr.yaml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RepoConfigPath(); err == nil {
+		t.Fatal("expect RepoConfigPath to fail with multiple config files")
+	}
+}
+
+func TestLoadConfigs_apiURL(t *testing.T) {
+	root, home := t.TempDir(), t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(home, Name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	t.Setenv(EnvXDGConfigHome, home)
+
+	// api-url can be set in the user config.
+	user := "api-url: https://github.example.com/api/v3/\n"
+	if err := os.WriteFile(filepath.Join(home, Name, "config"), []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfigs(); err != nil {
+		t.Fatalf("LoadConfigs failed: %s", err)
+	}
+
+	// A repository config can not send the token to another host.
+	repo := "profiles:\n  ghe:\n    api-url: https://evil.example.com/\n"
+	if err := os.WriteFile(filepath.Join(root, ".ghr.yaml"), []byte(repo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfigs(); err == nil {
+		t.Fatal("expect LoadConfigs to fail with api-url in the repository config")
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// partialSuffix is the suffix of a file being downloaded. Downloading is
+// resumed from it in the next run.
+const partialSuffix = ".part"
+
+// FindRelease returns the release of tag. Draft releases, which have no tag
+// until they are published, are found too.
+func (g *GHR) FindRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
+	release, err := g.GitHub.GetRelease(ctx, tag)
+	if err == nil {
+		return release, nil
+	}
+	if !errors.Is(err, ErrReleaseNotFound) {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+
+	release, err = g.GitHub.GetDraftRelease(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft release: %w", err)
+	}
+	if release == nil {
+		return nil, ErrReleaseNotFound
+	}
+	return release, nil
+}
+
+// DownloadAssets downloads the assets of the release whose names match any
+// of patterns (or all assets when patterns is empty) into dir in parallel.
+//
+// Each asset is written to a file with partialSuffix first, and downloading
+// is resumed from it if it exists. A file which already exists with the same
+// content is skipped. Downloaded files are verified against the digest of
+// the asset or the checksums file uploaded with the release, if any.
+func (g *GHR) DownloadAssets(ctx context.Context, releaseID int64, dir string, patterns []string, parallel int) error {
+	start := time.Now()
+	defer func() {
+		Debugf("DownloadAssets: time: %d ms", int(time.Since(start).Seconds()*1000))
+	}()
+
+	assets, err := g.GitHub.ListAssets(ctx, releaseID)
+	if err != nil {
+		return fmt.Errorf("failed to list assets: %w", err)
+	}
+
+	uploaded := make(map[string]*ReleaseAsset, len(assets))
+	var download []*ReleaseAsset
+	for _, asset := range assets {
+		uploaded[asset.GetName()] = asset
+		if len(patterns) == 0 || matchAny(patterns, asset.GetName(), false) {
+			download = append(download, asset)
+		}
+	}
+	if len(download) == 0 {
+		return fmt.Errorf("no assets match: %s", strings.Join(patterns, ", "))
+	}
+
+	checksums, err := g.uploadedChecksums(ctx, uploaded)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	semaphore := make(chan struct{}, parallel)
+	for _, asset := range download {
+		asset := asset
+		eg.Go(func() error {
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+			}()
+
+			dest := filepath.Join(dir, asset.GetName())
+			if err := g.downloadAsset(ctx, asset, dest, checksums[asset.GetName()]); err != nil {
+				return fmt.Errorf("failed to download asset: %s %w", asset.GetName(), err)
+			}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return fmt.Errorf("one of the goroutines failed: %w", err)
+	}
+
+	fmt.Fprintf(g.outStream, "==> Downloaded %d assets to %s\n", len(download), dir)
+	return nil
+}
+
+// downloadAsset downloads the asset to dest. checksum is the checksum of the
+// asset recorded in the checksums file, if any.
+func (g *GHR) downloadAsset(ctx context.Context, asset *ReleaseAsset, dest, checksum string) error {
+	name := asset.GetName()
+
+	if _, err := os.Stat(dest); err == nil {
+		same, err := sameAsset(dest, asset, checksum)
+		if err != nil {
+			return err
+		}
+		if same {
+			fmt.Fprintf(g.outStream, "--> Skipping: %15s (already downloaded)\n", name)
+			return nil
+		}
+	}
+
+	partial := dest + partialSuffix
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file stat: %w", err)
+	}
+
+	size, offset := int64(asset.GetSize()), fi.Size()
+	if offset > size {
+		offset = 0
+	}
+
+	if offset < size || size == 0 {
+		if offset > 0 {
+			fmt.Fprintf(g.outStream, "--> Resuming: %15s (from %s)\n", name, formatBytes(offset))
+		} else {
+			fmt.Fprintf(g.outStream, "--> Downloading: %15s\n", name)
+		}
+
+		rc, start, err := g.GitHub.DownloadAsset(ctx, asset.GetID(), offset)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		if err := f.Truncate(start); err != nil {
+			return fmt.Errorf("failed to truncate file: %w", err)
+		}
+		if _, err := f.Seek(start, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek file: %w", err)
+		}
+		if _, err := io.Copy(f, rc); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	algo, verified, err := verifyAsset(partial, asset, checksum)
+	if err != nil {
+		// Start over in the next run.
+		os.Remove(partial)
+		return err
+	}
+
+	if err := os.Rename(partial, dest); err != nil {
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+
+	if verified {
+		fmt.Fprintf(g.outStream, "--> Downloaded: %15s (%s verified)\n", name, algo)
+	} else {
+		fmt.Fprintf(g.outStream, "--> Downloaded: %15s (no checksum to verify)\n", name)
+	}
+	return nil
+}
+
+// verifyAsset verifies the downloaded file against the digest of the asset or
+// checksum recorded in the checksums file. It returns false when there is
+// nothing to verify with, and an error when the content differs.
+func verifyAsset(filename string, asset *ReleaseAsset, checksum string) (ChecksumAlgorithm, bool, error) {
+	checksum = assetChecksum(asset, checksum)
+	algo, ok := checksumAlgorithmOf(checksum)
+	if !ok {
+		return algo, false, nil
+	}
+
+	sum, err := FileChecksum(filename, algo)
+	if err != nil {
+		return algo, false, err
+	}
+	if !strings.EqualFold(sum, checksum) {
+		return algo, false, fmt.Errorf("%s checksum mismatch: got %s, want %s", algo, sum, checksum)
+	}
+	return algo, true, nil
+}
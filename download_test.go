@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// assetsGitHub is a GitHub which serves assets from memory.
+type assetsGitHub struct {
+	GitHub
+	names    []string
+	contents map[string]string
+
+	// offsets are the offsets requested to download each asset.
+	mu      sync.Mutex
+	offsets map[string]int64
+}
+
+func (g *assetsGitHub) ListAssets(ctx context.Context, releaseID int64) ([]*ReleaseAsset, error) {
+	var assets []*ReleaseAsset
+	for i, name := range g.names {
+		assets = append(assets, &ReleaseAsset{ReleaseAsset: &github.ReleaseAsset{
+			ID:   github.Int64(int64(i)),
+			Name: github.String(name),
+			Size: github.Int(len(g.contents[name])),
+		}})
+	}
+	return assets, nil
+}
+
+func (g *assetsGitHub) DownloadAsset(ctx context.Context, assetID, offset int64) (io.ReadCloser, int64, error) {
+	name := g.names[assetID]
+	g.mu.Lock()
+	g.offsets[name] = offset
+	g.mu.Unlock()
+	return io.NopCloser(strings.NewReader(g.contents[name][offset:])), offset, nil
+}
+
+func newAssetsGitHub(contents map[string]string, corrupt string) *assetsGitHub {
+	var sums bytes.Buffer
+	g := &assetsGitHub{contents: contents, offsets: make(map[string]int64)}
+	for name, content := range contents {
+		g.names = append(g.names, name)
+		sum := sha256.Sum256([]byte(content))
+		if name == corrupt {
+			sum = sha256.Sum256(nil)
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+	g.names = append(g.names, "SHA256SUMS")
+	g.contents["SHA256SUMS"] = sums.String()
+	return g
+}
+
+func TestGHR_DownloadAssets(t *testing.T) {
+	dir := t.TempDir()
+	g := newAssetsGitHub(map[string]string{
+		"linux_amd64":  "linux binary",
+		"darwin_amd64": "darwin binary",
+	}, "")
+
+	// darwin_amd64 is partially downloaded, and linux_amd64 is already
+	// downloaded.
+	if err := os.WriteFile(filepath.Join(dir, "darwin_amd64"+partialSuffix), []byte("darwin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "linux_amd64"), []byte("linux binary"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	ghr := &GHR{GitHub: g, outStream: &buf}
+	if err := ghr.DownloadAssets(context.TODO(), 1, dir, []string{"*_amd64"}, 2); err != nil {
+		t.Fatalf("DownloadAssets failed: %s\n%s", err, buf.String())
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "darwin_amd64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "darwin binary" {
+		t.Fatalf("darwin_amd64 = %q; want %q", got, "darwin binary")
+	}
+	if g.offsets["darwin_amd64"] != int64(len("darwin")) {
+		t.Fatalf("darwin_amd64 is downloaded from %d; want to resume from %d", g.offsets["darwin_amd64"], len("darwin"))
+	}
+	if _, ok := g.offsets["linux_amd64"]; ok {
+		t.Fatal("linux_amd64 is downloaded again")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "SHA256SUMS")); err == nil {
+		t.Fatal("SHA256SUMS is downloaded while it does not match the pattern")
+	}
+
+	for _, want := range []string{
+		"--> Resuming:    darwin_amd64 (from 6 B)",
+		"--> Downloaded:    darwin_amd64 (sha256 verified)",
+		"--> Skipping:     linux_amd64 (already downloaded)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("DownloadAssets prints:\n%s\nwant to contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestGHR_DownloadAssets_mismatch(t *testing.T) {
+	dir := t.TempDir()
+	g := newAssetsGitHub(map[string]string{"linux_amd64": "linux binary"}, "linux_amd64")
+
+	ghr := &GHR{GitHub: g, outStream: io.Discard}
+	err := ghr.DownloadAssets(context.TODO(), 1, dir, []string{"linux_*"}, 1)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expect DownloadAssets to fail by checksum mismatch: %v", err)
+	}
+
+	// The broken file is not left to be resumed.
+	for _, name := range []string{"linux_amd64", "linux_amd64" + partialSuffix} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Fatalf("%s is left", name)
+		}
+	}
+}
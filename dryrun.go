@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// dryRunGitHub is a GitHub which only reads from the GitHub API. Instead of
+// creating, editing or deleting releases and assets, it prints what would be
+// done. Read-only methods are passed to the embedded GitHub.
+type dryRunGitHub struct {
+	GitHub
+
+	outStream io.Writer
+
+	// lastID is the ID given to the last release which would be created.
+	// IDs of such releases are negative so that they are never sent to the
+	// GitHub API.
+	lastID int64
+
+	// releases holds releases read or created in this run so that edits can
+	// be applied to them.
+	releases map[int64]*github.RepositoryRelease
+
+	// assets holds assets which would be uploaded to releases created in
+	// this run. UploadAsset is called concurrently, so it's guarded by mu.
+	mu     sync.Mutex
+	assets map[int64][]*ReleaseAsset
+
+	// assetNames holds the names of assets listed from GitHub so that the
+	// assets which would be deleted are printed by name.
+	assetNames map[int64]string
+}
+
+func (d *dryRunGitHub) remember(release *github.RepositoryRelease) *github.RepositoryRelease {
+	if release == nil {
+		return nil
+	}
+	if d.releases == nil {
+		d.releases = map[int64]*github.RepositoryRelease{}
+	}
+	d.releases[release.GetID()] = release
+	return release
+}
+
+// GetRelease gets the release with the tag from GitHub.
+func (d *dryRunGitHub) GetRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
+	release, err := d.GitHub.GetRelease(ctx, tag)
+	return d.remember(release), err
+}
+
+// GetDraftRelease gets the draft release with the tag from GitHub.
+func (d *dryRunGitHub) GetDraftRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
+	release, err := d.GitHub.GetDraftRelease(ctx, tag)
+	return d.remember(release), err
+}
+
+// GetLatestRelease returns the release which would be published as the
+// latest one in this run, or the latest release on GitHub.
+func (d *dryRunGitHub) GetLatestRelease(ctx context.Context) (*github.RepositoryRelease, error) {
+	for _, release := range d.releases {
+		if release.GetID() < 0 && !release.GetDraft() && !release.GetPrerelease() && release.GetMakeLatest() != "false" {
+			return release, nil
+		}
+	}
+	return d.GitHub.GetLatestRelease(ctx)
+}
+
+func (d *dryRunGitHub) printf(format string, args ...interface{}) {
+	fmt.Fprintf(d.outStream, "[dry-run] "+format+"\n", args...)
+}
+
+// CreateRelease prints the release which would be created.
+func (d *dryRunGitHub) CreateRelease(ctx context.Context, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	d.lastID--
+	d.printf("create release %s (%s)", req.GetTagName(), describeRelease(req))
+
+	release := *req
+	release.ID = github.Int64(d.lastID)
+	release.UploadURL = github.String("https://uploads.github.com/repos/")
+	return d.remember(&release), nil
+}
+
+// EditRelease prints the change which would be applied to the release.
+func (d *dryRunGitHub) EditRelease(ctx context.Context, releaseID int64, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	if req.Draft != nil && !*req.Draft && req.TagName == nil {
+		d.printf("publish release %s", d.releaseName(releaseID))
+	} else {
+		d.printf("edit release %s (%s)", d.releaseName(releaseID), describeRelease(req))
+	}
+
+	release := github.RepositoryRelease{ID: github.Int64(releaseID)}
+	if known, ok := d.releases[releaseID]; ok {
+		release = *known
+	}
+	mergeRelease(&release, req)
+	return d.remember(&release), nil
+}
+
+// DeleteRelease prints the release which would be deleted.
+func (d *dryRunGitHub) DeleteRelease(ctx context.Context, releaseID int64) error {
+	d.printf("delete release %s", d.releaseName(releaseID))
+	return nil
+}
+
+// DeleteTag prints the tag which would be deleted.
+func (d *dryRunGitHub) DeleteTag(ctx context.Context, tag string) error {
+	d.printf("delete tag %s", tag)
+	return nil
+}
+
+// UploadAsset prints the asset which would be uploaded.
+func (d *dryRunGitHub) UploadAsset(ctx context.Context, releaseID int64, filename string, progress UploadProgress) (*github.ReleaseAsset, error) {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file stat: %w", err)
+	}
+
+	d.printf("upload asset %s (%d bytes) to release %s", filepath.Base(filename), fi.Size(), d.releaseName(releaseID))
+	if progress != nil {
+		progress.Sent(fi.Size())
+	}
+	asset := &github.ReleaseAsset{
+		Name: github.String(filepath.Base(filename)),
+		Size: github.Int(int(fi.Size())),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.assets == nil {
+		d.assets = map[int64][]*ReleaseAsset{}
+	}
+	d.assets[releaseID] = append(d.assets[releaseID], &ReleaseAsset{ReleaseAsset: asset})
+	return asset, nil
+}
+
+// DeleteAsset prints the asset which would be deleted.
+func (d *dryRunGitHub) DeleteAsset(ctx context.Context, assetID int64) error {
+	d.mu.Lock()
+	name, ok := d.assetNames[assetID]
+	d.mu.Unlock()
+	if ok {
+		d.printf("delete asset %s (ID: %d)", name, assetID)
+	} else {
+		d.printf("delete asset %d", assetID)
+	}
+	return nil
+}
+
+// ListAssets lists assets of an existing release. A release which would be
+// created has only the assets which would be uploaded.
+func (d *dryRunGitHub) ListAssets(ctx context.Context, releaseID int64) ([]*ReleaseAsset, error) {
+	if releaseID < 0 {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return append([]*ReleaseAsset{}, d.assets[releaseID]...), nil
+	}
+
+	assets, err := d.GitHub.ListAssets(ctx, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.assetNames == nil {
+		d.assetNames = map[int64]string{}
+	}
+	for _, asset := range assets {
+		d.assetNames[asset.GetID()] = asset.GetName()
+	}
+	return assets, nil
+}
+
+// SetUploadURL does nothing since no asset is uploaded.
+func (d *dryRunGitHub) SetUploadURL(urlStr string) error {
+	return nil
+}
+
+func (d *dryRunGitHub) releaseName(releaseID int64) string {
+	if releaseID < 0 {
+		return "(new)"
+	}
+	return fmt.Sprintf("%d", releaseID)
+}
+
+// mergeRelease sets the fields of the release request to the release.
+func mergeRelease(release, req *github.RepositoryRelease) {
+	if req.TagName != nil {
+		release.TagName = req.TagName
+	}
+	if req.TargetCommitish != nil {
+		release.TargetCommitish = req.TargetCommitish
+	}
+	if req.Name != nil {
+		release.Name = req.Name
+	}
+	if req.Body != nil {
+		release.Body = req.Body
+	}
+	if req.Draft != nil {
+		release.Draft = req.Draft
+	}
+	if req.Prerelease != nil {
+		release.Prerelease = req.Prerelease
+	}
+	if req.MakeLatest != nil {
+		release.MakeLatest = req.MakeLatest
+	}
+}
+
+// describeRelease returns the fields set in the release request.
+func describeRelease(req *github.RepositoryRelease) string {
+	var fields []string
+	if req.Name != nil && *req.Name != "" {
+		fields = append(fields, fmt.Sprintf("name=%q", *req.Name))
+	}
+	if req.TargetCommitish != nil && *req.TargetCommitish != "" {
+		fields = append(fields, fmt.Sprintf("commitish=%s", *req.TargetCommitish))
+	}
+	if req.Draft != nil {
+		fields = append(fields, fmt.Sprintf("draft=%t", *req.Draft))
+	}
+	if req.Prerelease != nil {
+		fields = append(fields, fmt.Sprintf("prerelease=%t", *req.Prerelease))
+	}
+	if req.MakeLatest != nil {
+		fields = append(fields, fmt.Sprintf("latest=%s", *req.MakeLatest))
+	}
+	if req.GenerateReleaseNotes != nil && *req.GenerateReleaseNotes {
+		fields = append(fields, "generate-notes=true")
+	}
+	if req.Body != nil && *req.Body != "" {
+		fields = append(fields, fmt.Sprintf("body=%d bytes", len(*req.Body)))
+	}
+	return strings.Join(fields, " ")
+}
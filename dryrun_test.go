@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestDryRunGitHub(t *testing.T) {
+	var buf bytes.Buffer
+
+	// Any request to the GitHub API panics since no GitHub is embedded.
+	d := &dryRunGitHub{outStream: &buf}
+	ctx := context.TODO()
+
+	release, err := d.CreateRelease(ctx, &github.RepositoryRelease{
+		TagName:    github.String("v1.0.0"),
+		Name:       github.String("v1.0.0"),
+		Draft:      github.Bool(true),
+		Prerelease: github.Bool(false),
+	})
+	if err != nil {
+		t.Fatal("CreateRelease failed:", err)
+	}
+	if *release.ID >= 0 {
+		t.Fatalf("release ID = %d, want negative", *release.ID)
+	}
+
+	assets, err := d.ListAssets(ctx, *release.ID)
+	if err != nil {
+		t.Fatal("ListAssets failed:", err)
+	}
+	if len(assets) != 0 {
+		t.Fatalf("ListAssets number = %d, want 0", len(assets))
+	}
+
+	if err := d.SetUploadURL(*release.UploadURL); err != nil {
+		t.Fatal("SetUploadURL failed:", err)
+	}
+
+	if _, err := d.UploadAsset(ctx, *release.ID, filepath.Join(TestDir, "darwin_386"), nil); err != nil {
+		t.Fatal("UploadAsset failed:", err)
+	}
+
+	if _, err := d.EditRelease(ctx, *release.ID, &github.RepositoryRelease{Draft: github.Bool(false)}); err != nil {
+		t.Fatal("EditRelease failed:", err)
+	}
+
+	want := `[dry-run] create release v1.0.0 (name="v1.0.0" draft=true prerelease=false)
+[dry-run] upload asset darwin_386 (11 bytes) to release (new)
+[dry-run] publish release (new)
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("dry-run output %q, want %q", got, want)
+	}
+}
+
+func TestDryRunGitHub_delete(t *testing.T) {
+	var buf bytes.Buffer
+	ghr := &GHR{
+		GitHub: &dryRunGitHub{
+			GitHub:    newAssetsGitHub(map[string]string{"ghr_linux_amd64.tar.gz": "ghr"}, ""),
+			outStream: &buf,
+		},
+		NoTagWait: true,
+		outStream: &buf,
+	}
+
+	// Nothing is deleted, so ghr does not wait for the tag to be deleted.
+	start := time.Now()
+	if err := ghr.DeleteRelease(context.TODO(), 1, "v1.0.0"); err != nil {
+		t.Fatal("DeleteRelease failed:", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("DeleteRelease takes %s in dry-run mode", d)
+	}
+
+	if err := ghr.DeleteAssets(context.TODO(), 1, []string{"dist/ghr_linux_amd64.tar.gz"}, 1); err != nil {
+		t.Fatal("DeleteAssets failed:", err)
+	}
+
+	want := `[dry-run] delete release 1
+[dry-run] delete tag v1.0.0
+--> Deleting: ghr_linux_amd64.tar.gz
+[dry-run] delete asset ghr_linux_amd64.tar.gz (ID: 0)
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("dry-run output %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvFlagPrefix is the prefix of env vars which set flags, e.g.,
+// GHR_PARALLEL for -parallel.
+const EnvFlagPrefix = "GHR_"
+
+// noEnvFlags are the flags which are not set by env vars. GHR_VERSION is the
+// version to install in the GitHub Action and GHR_DEBUG is read as it is.
+var noEnvFlags = map[string]bool{
+	"version": true,
+	"debug":   true,
+}
+
+// secretFlags are the flags whose values are masked in debug output.
+var secretFlags = map[string]bool{
+	"token":           true,
+	"app-private-key": true,
+}
+
+// legacyFlagEnvs are the env vars which set flags from before GHR_* env vars.
+// GHR_* env vars take precedence over them.
+var legacyFlagEnvs = map[string]string{
+	"token":   EnvGitHubToken,
+	"api-url": EnvGitHubAPI,
+}
+
+// FlagEnv returns the name of the env var which sets the flag, or "" if the
+// flag is not set by env vars. Short aliases like -u have none.
+func FlagEnv(name string) string {
+	if len(name) <= 1 || noEnvFlags[name] {
+		return ""
+	}
+	return EnvFlagPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// ApplyEnv sets the flags which are not set on the command line from env
+// vars. A flag which can be specified multiple times, like -include, takes
+// comma separated values (see splitEnvValues). Like config files, env vars
+// do not set the flags which conflict with the ones on the command line (see
+// configConflicts), e.g., GHR_BODY is ignored with -body-file.
+func ApplyEnv(flags *flag.FlagSet) error {
+	// Aliases like -owner and -username share the same value.
+	set := make(map[flag.Value]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Value] = true
+	})
+	given := make(map[flag.Value]bool, len(set))
+	for v := range set {
+		given[v] = true
+	}
+
+	// VisitAll visits flags in lexicographical order, so the env var of
+	// -owner is used over the one of -username.
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		env := FlagEnv(f.Name)
+		if err != nil || env == "" || set[f.Value] || conflicted(flags, given, f.Name) {
+			return
+		}
+		if err = setFromEnv(flags, f, env); err == nil && os.Getenv(env) != "" {
+			set[f.Value] = true
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range legacyFlagEnvs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := flags.Lookup(name)
+		if f == nil || set[f.Value] || conflicted(flags, given, name) {
+			continue
+		}
+		if err := setFromEnv(flags, f, legacyFlagEnvs[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setFromEnv(flags *flag.FlagSet, f *flag.Flag, env string) error {
+	v := os.Getenv(env)
+	if v == "" {
+		return nil
+	}
+
+	values := []string{v}
+	if _, ok := f.Value.(*stringsFlag); ok {
+		values = splitEnvValues(v)
+	}
+	for _, value := range values {
+		if err := flags.Set(f.Name, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("invalid value %q of %s: %w", v, env, err)
+		}
+	}
+	if secretFlags[f.Name] {
+		v = maskString(v)
+	}
+	Debugf("Env: %s = %s", f.Name, v)
+	return nil
+}
+
+// splitEnvValues splits the value of an env var into the values of a flag
+// which can be specified multiple times. "\," is a literal comma, e.g., in
+// a pattern of -include.
+func splitEnvValues(v string) []string {
+	var (
+		values []string
+		b      strings.Builder
+	)
+	for i := 0; i < len(v); i++ {
+		switch {
+		case v[i] == '\\' && i+1 < len(v) && v[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case v[i] == ',':
+			values = append(values, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(v[i])
+		}
+	}
+	return append(values, b.String())
+}
+
+// EnvHelp adds the env vars of flags to the help text, e.g.,
+// "-parallel=-1 ($GHR_PARALLEL)", to the lines which begin with flags.
+func EnvHelp(text string, flags *flag.FlagSet) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		var envs []string
+		for _, name := range strings.Split(line, ", ") {
+			name, _, _ = strings.Cut(strings.TrimPrefix(name, "-"), "=")
+			if flags.Lookup(name) == nil {
+				continue
+			}
+			if env := FlagEnv(name); env != "" {
+				envs = append(envs, "$"+env)
+			}
+		}
+		if len(envs) != 0 {
+			lines[i] = fmt.Sprintf("%s (%s)", line, strings.Join(envs, ", "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
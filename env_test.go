@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlagEnv(t *testing.T) {
+	cases := map[string]string{
+		"parallel":          "GHR_PARALLEL",
+		"latest-tag-prefix": "GHR_LATEST_TAG_PREFIX",
+		"u":                 "",
+		"version":           "",
+		"debug":             "",
+	}
+	for name, want := range cases {
+		if got := FlagEnv(name); got != want {
+			t.Fatalf("FlagEnv(%q) = %q; want %q", name, got, want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("GHR_OWNER", "env-owner")
+	t.Setenv("GHR_REPOSITORY", "env-repo")
+	t.Setenv("GHR_INCLUDE", "*.zip, *.tar.gz")
+	t.Setenv("GHR_DRAFT", "1")
+	t.Setenv("GHR_VERSION", "v0.17.0")
+	t.Setenv("GHR_API_URL", "https://ghr.example.com/api/v3/")
+	t.Setenv(EnvGitHubAPI, "https://github.example.com/api/v3/")
+
+	var (
+		repository repositoryFlags
+		include    stringsFlag
+		draft      bool
+		version    bool
+	)
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	repository.register(flags)
+	flags.Var(&include, "include", "")
+	flags.BoolVar(&draft, "draft", false, "")
+	flags.BoolVar(&version, "version", false, "")
+
+	if err := flags.Parse([]string{"-r", "flag-repo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyEnv(flags); err != nil {
+		t.Fatalf("ApplyEnv failed: %s", err)
+	}
+
+	if repository.owner != "env-owner" {
+		t.Fatalf("owner = %q; want GHR_OWNER", repository.owner)
+	}
+	if repository.repo != "flag-repo" {
+		t.Fatalf("repo = %q; want the flag", repository.repo)
+	}
+	if repository.apiURL != "https://ghr.example.com/api/v3/" {
+		t.Fatalf("api-url = %q; want GHR_API_URL over GITHUB_API", repository.apiURL)
+	}
+	if !reflect.DeepEqual(include, stringsFlag{"*.zip", "*.tar.gz"}) {
+		t.Fatalf("include = %q; want both values of GHR_INCLUDE", include)
+	}
+	if !draft {
+		t.Fatal("draft = false; want GHR_DRAFT")
+	}
+	if version {
+		t.Fatal("version = true; want GHR_VERSION to be ignored")
+	}
+
+	t.Setenv("GHR_DRAFT", "maybe")
+	flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.BoolVar(&draft, "draft", false, "")
+	err := ApplyEnv(flags)
+	if err == nil || !strings.Contains(err.Error(), "GHR_DRAFT") {
+		t.Fatalf("ApplyEnv = %v; want the error naming GHR_DRAFT", err)
+	}
+}
+
+func TestEnvHelp(t *testing.T) {
+	var owner string
+	var parallel int
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.StringVar(&owner, "owner", "", "")
+	flags.StringVar(&owner, "u", "", "")
+	flags.IntVar(&parallel, "parallel", -1, "")
+	flags.Bool("debug", false, "")
+
+	text := "Options:\n\n-owner, -u\n\tOwner\n\n-parallel=-1\n\tParallelism\n\n-debug\n\tDebug\n"
+	want := "Options:\n\n-owner, -u ($GHR_OWNER)\n\tOwner\n\n-parallel=-1 ($GHR_PARALLEL)\n\tParallelism\n\n-debug\n\tDebug\n"
+	if got := EnvHelp(text, flags); got != want {
+		t.Fatalf("EnvHelp =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyEnv_conflicts(t *testing.T) {
+	t.Setenv("GHR_BODY", "env body")
+	t.Setenv("GHR_BODY_FILE", "env.md")
+
+	cases := []struct {
+		args           []string
+		body, bodyFile string
+	}{
+		// GHR_BODY is ignored with -body-file and vice versa.
+		{[]string{"-body-file", "flag.md"}, "", "flag.md"},
+		{[]string{"-b", "flag body"}, "flag body", ""},
+	}
+
+	for i, tc := range cases {
+		var body, bodyFile, changelog string
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.StringVar(&body, "body", "", "")
+		flags.StringVar(&body, "b", "", "")
+		flags.StringVar(&bodyFile, "body-file", "", "")
+		flags.StringVar(&changelog, "body-from-changelog", "", "")
+		if err := flags.Parse(tc.args); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ApplyEnv(flags); err != nil {
+			t.Fatalf("#%d ApplyEnv failed: %s", i, err)
+		}
+		if body != tc.body || bodyFile != tc.bodyFile {
+			t.Fatalf("#%d body, body-file = %q, %q; want %q, %q", i, body, bodyFile, tc.body, tc.bodyFile)
+		}
+	}
+}
+
+func TestSplitEnvValues(t *testing.T) {
+	cases := map[string][]string{
+		"*.zip":             {"*.zip"},
+		"*.zip,*.tar.gz":    {"*.zip", "*.tar.gz"},
+		`a\,b.zip,*.tar.gz`: {"a,b.zip", "*.tar.gz"},
+		`a\b`:               {`a\b`},
+		"*.zip,":            {"*.zip", ""},
+	}
+	for v, want := range cases {
+		if got := splitEnvValues(v); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitEnvValues(%q) = %q; want %q", v, got, want)
+		}
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/google/go-github/v66/github"
-	"github.com/hashicorp/go-version"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -17,6 +19,30 @@ import (
 type GHR struct {
 	GitHub GitHub
 
+	// ChecksumFile is the name of the checksums file uploaded along with
+	// the assets. When it's empty, no checksums file is uploaded.
+	ChecksumFile      string
+	ChecksumAlgorithm ChecksumAlgorithm
+
+	// SkipExisting skips uploading assets which are already uploaded with
+	// the same content, and replaces the ones whose content differs.
+	SkipExisting bool
+
+	// KeepGoing keeps uploading the other assets when one of them fails and
+	// reports the results of all assets.
+	KeepGoing bool
+
+	// Update is applied to the existing release instead of using it as it
+	// is. Only its non-nil fields are changed.
+	Update *github.RepositoryRelease
+
+	// NoTagWait skips waiting for the tag to be deleted with the release,
+	// e.g., in dry-run mode where nothing is deleted.
+	NoTagWait bool
+
+	// journal records objects changed on GitHub in this run.
+	journal journal
+
 	outStream io.Writer
 }
 
@@ -27,7 +53,7 @@ func (g *GHR) CreateRelease(ctx context.Context, req *github.RepositoryRelease,
 	// create it without any check (it can).
 	if *req.Draft {
 		fmt.Fprintln(g.outStream, "==> Create a draft release")
-		return g.GitHub.CreateRelease(ctx, req)
+		return g.createRelease(ctx, req)
 	}
 
 	// Always create release as draft first. After uploading assets, turn off
@@ -52,7 +78,7 @@ func (g *GHR) CreateRelease(ctx context.Context, req *github.RepositoryRelease,
 		}
 
 		fmt.Fprintln(g.outStream, "==> Create a new release")
-		return g.GitHub.CreateRelease(ctx, req)
+		return g.createRelease(ctx, req)
 	}
 
 	// recreate is not true. Then use that existing release.
@@ -60,6 +86,10 @@ func (g *GHR) CreateRelease(ctx context.Context, req *github.RepositoryRelease,
 		Debugf("Release (with tag %s) exists: use existing one",
 			*req.TagName)
 
+		if g.Update != nil {
+			return g.UpdateRelease(ctx, release, g.Update)
+		}
+
 		fmt.Fprintf(g.outStream, "WARNING: found release (%s). Use existing one.\n",
 			*req.TagName)
 		return release, nil
@@ -71,55 +101,73 @@ func (g *GHR) CreateRelease(ctx context.Context, req *github.RepositoryRelease,
 	if err := g.DeleteRelease(ctx, *release.ID, *req.TagName); err != nil {
 		return nil, err
 	}
+	g.journal.recreateRelease(release)
 
-	return g.GitHub.CreateRelease(ctx, req)
+	return g.createRelease(ctx, req)
 }
 
-func (g *GHR) GetLatestRelease(ctx context.Context) (*github.RepositoryRelease, error) {
-	release, err := g.GitHub.GetLatestRelease(ctx)
+// createRelease creates a new release and records it as created in this run
+// along with whether its tag exists before this run.
+func (g *GHR) createRelease(ctx context.Context, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	tagExists, err := g.GitHub.TagExists(ctx, req.GetTagName())
 	if err != nil {
-		return nil, fmt.Errorf("Unable to fetch latest Github release: %w", err)
+		// The tag is kept on rollback when it's unknown whether it's
+		// created in this run.
+		Debugf("Failed to check tag %s: %s", req.GetTagName(), err)
+		tagExists = true
 	}
-	return release, err
-}
 
-func (g *GHR) IsNewerSemverRelease(newRelease *github.RepositoryRelease, latestRelease *github.RepositoryRelease) (bool, error) {
-	newReleaseVer, error := version.NewVersion(*newRelease.TagName)
-	if error != nil {
-		return false, fmt.Errorf("Unable to parse new release version as semver%s: %w", *newRelease.TagName, error)
-	}
-	latestReleaseVer, error := version.NewVersion(*latestRelease.TagName)
-	if error != nil {
-		return false, fmt.Errorf("Unable to parse latest release version as semver %s: %w", *newRelease.TagName, error)
+	release, err := g.GitHub.CreateRelease(ctx, req)
+	if err != nil {
+		return nil, err
 	}
+	g.journal.createRelease(release, !tagExists)
+	return release, nil
+}
 
-	if latestReleaseVer.LessThan(newReleaseVer) {
-		return true, nil
+func (g *GHR) GetLatestRelease(ctx context.Context) (*github.RepositoryRelease, error) {
+	release, err := g.GitHub.GetLatestRelease(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch latest Github release: %w", err)
 	}
-	return false, nil
+	return release, err
 }
 
 // DeleteRelease removes an existing release, if it exists. If it does not exist,
 // DeleteRelease returns an error
 func (g *GHR) DeleteRelease(ctx context.Context, releaseID int64, tag string) error {
-
-	err := g.GitHub.DeleteRelease(ctx, releaseID)
-	if err != nil {
+	if err := g.deleteRelease(ctx, releaseID, tag); err != nil {
 		return err
 	}
 
-	err = g.GitHub.DeleteTag(ctx, tag)
-	if err != nil {
-		return err
+	if g.NoTagWait {
+		return nil
 	}
 
 	// This is because sometimes the process of creating a release on GitHub
 	// is faster than deleting a tag.
-	time.Sleep(5 * time.Second)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(5 * time.Second):
+	}
 
 	return nil
 }
 
+// deleteRelease removes the release and its tag unless tag is empty. Unlike
+// DeleteRelease, it does not wait for the tag to be deleted.
+func (g *GHR) deleteRelease(ctx context.Context, releaseID int64, tag string) error {
+	if err := g.GitHub.DeleteRelease(ctx, releaseID); err != nil {
+		return err
+	}
+
+	if tag == "" {
+		return nil
+	}
+	return g.GitHub.DeleteTag(ctx, tag)
+}
+
 // UploadAssets uploads the designated assets in parallel (determined by parallelism setting)
 func (g *GHR) UploadAssets(ctx context.Context, releaseID int64, localAssets []string, parallel int) error {
 	start := time.Now()
@@ -127,7 +175,32 @@ func (g *GHR) UploadAssets(ctx context.Context, releaseID int64, localAssets []s
 		Debugf("UploadAssets: time: %d ms", int(time.Since(start).Seconds()*1000))
 	}()
 
-	eg, ctx := errgroup.WithContext(ctx)
+	if g.ChecksumFile != "" {
+		checksumFile, err := g.writeChecksumFile(localAssets)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(filepath.Dir(checksumFile))
+
+		localAssets = append(localAssets[:len(localAssets):len(localAssets)], checksumFile)
+	}
+
+	if g.SkipExisting {
+		var err error
+		localAssets, err = g.skipExistingAssets(ctx, releaseID, localAssets, parallel)
+		if err != nil {
+			return err
+		}
+	}
+
+	progress := newUploadProgress(g.outStream)
+
+	// In keep-going mode, a failed upload does not cancel the others.
+	eg, uploadCtx := &errgroup.Group{}, ctx
+	if !g.KeepGoing {
+		eg, uploadCtx = errgroup.WithContext(ctx)
+	}
+
 	semaphore := make(chan struct{}, parallel)
 	for _, localAsset := range localAssets {
 		localAsset := localAsset
@@ -137,22 +210,233 @@ func (g *GHR) UploadAssets(ctx context.Context, releaseID int64, localAssets []s
 				<-semaphore
 			}()
 
-			fmt.Fprintf(g.outStream, "--> Uploading: %15s\n", filepath.Base(localAsset))
-			_, err := g.GitHub.UploadAsset(ctx, releaseID, localAsset)
+			fi, err := os.Stat(localAsset)
 			if err != nil {
+				return fmt.Errorf("failed to get file stat: %w", err)
+			}
+
+			progress.Printf("--> Uploading: %15s\n", filepath.Base(localAsset))
+			asset := progress.Add(filepath.Base(localAsset), fi.Size())
+			uploaded, err := g.GitHub.UploadAsset(uploadCtx, releaseID, localAsset, asset)
+			progress.Finish(asset, err)
+			if err != nil {
+				if g.KeepGoing {
+					return nil
+				}
 				return fmt.Errorf("failed to upload asset: %s %w", localAsset, err)
 			}
+			g.journal.uploadAsset(uploaded)
 			return nil
 		})
 	}
 
-	if err := eg.Wait(); err != nil {
+	err := eg.Wait()
+	progress.Stop()
+	if err != nil {
 		return fmt.Errorf("one of the goroutines failed: %w", err)
 	}
 
+	if g.KeepGoing {
+		results := progress.Results()
+		printUploadResults(g.outStream, results)
+		for _, result := range results {
+			if result.Err != nil {
+				return &UploadError{Results: results}
+			}
+		}
+	}
+
 	return nil
 }
 
+// UploadResult is the result of uploading an asset.
+type UploadResult struct {
+	Name    string
+	Size    int64
+	Retries int
+	Elapsed time.Duration
+	Err     error
+}
+
+// UploadError is returned by UploadAssets in keep-going mode when one or more
+// assets failed to upload.
+type UploadError struct {
+	Results []*UploadResult
+}
+
+func (e *UploadError) Error() string {
+	var failed []string
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result.Name)
+		}
+	}
+	return fmt.Sprintf("%d of %d assets failed to upload: %s",
+		len(failed), len(e.Results), strings.Join(failed, ", "))
+}
+
+// printUploadResults prints the results of uploading assets as a table.
+func printUploadResults(w io.Writer, results []*UploadResult) {
+	fmt.Fprintln(w, "==> Upload results")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "    NAME\tSIZE\tRETRIES\tTIME\tRESULT")
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = fmt.Sprintf("failed: %s", result.Err)
+		}
+		fmt.Fprintf(tw, "    %s\t%s\t%d\t%s\t%s\n",
+			result.Name, formatBytes(result.Size), result.Retries, result.Elapsed.Round(time.Millisecond), status)
+	}
+	tw.Flush()
+}
+
+// writeChecksumFile writes checksums of the local assets into ChecksumFile in
+// a temporary directory and returns its path.
+func (g *GHR) writeChecksumFile(localAssets []string) (string, error) {
+	dir, err := os.MkdirTemp("", "ghr")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	checksumFile := filepath.Join(dir, g.ChecksumFile)
+	f, err := os.Create(checksumFile)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create checksums file: %w", err)
+	}
+
+	fmt.Fprintf(g.outStream, "--> Computing %s checksums: %s\n", g.ChecksumAlgorithm, g.ChecksumFile)
+	err = WriteChecksums(f, localAssets, g.ChecksumAlgorithm)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to close checksums file: %w", cerr)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return checksumFile, nil
+}
+
+// skipExistingAssets compares local assets with the uploaded ones and returns
+// the assets which need to be uploaded. Uploaded assets whose content differs
+// from the local one are deleted so that they can be uploaded again.
+//
+// The content is compared by size and the digest of the uploaded asset. When
+// GitHub does not provide the digest, the checksums file uploaded with the
+// release is used instead.
+func (g *GHR) skipExistingAssets(ctx context.Context, releaseID int64, localAssets []string, parallel int) ([]string, error) {
+	assets, err := g.GitHub.ListAssets(ctx, releaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assets: %w", err)
+	}
+
+	uploaded := make(map[string]*ReleaseAsset, len(assets))
+	for _, asset := range assets {
+		uploaded[*asset.Name] = asset
+	}
+
+	checksums, err := g.uploadedChecksums(ctx, uploaded)
+	if err != nil {
+		return nil, err
+	}
+
+	var upload, changed []string
+	for _, localAsset := range localAssets {
+		name := filepath.Base(localAsset)
+		asset, ok := uploaded[name]
+		if !ok {
+			upload = append(upload, localAsset)
+			continue
+		}
+
+		same, err := sameAsset(localAsset, asset, checksums[name])
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			fmt.Fprintf(g.outStream, "--> Skipping: %15s (already uploaded)\n", name)
+			continue
+		}
+
+		changed = append(changed, localAsset)
+		upload = append(upload, localAsset)
+	}
+
+	if len(changed) != 0 {
+		if err := g.DeleteAssets(ctx, releaseID, changed, parallel); err != nil {
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// uploadedChecksums downloads and parses the checksums file uploaded with the
+// release: ChecksumFile, SHA256SUMS or SHA512SUMS. It returns nil when there
+// is none.
+func (g *GHR) uploadedChecksums(ctx context.Context, uploaded map[string]*ReleaseAsset) (map[string]string, error) {
+	for _, name := range []string{g.ChecksumFile, checksumSHA256.DefaultFilename(), checksumSHA512.DefaultFilename()} {
+		asset, ok := uploaded[name]
+		if name == "" || !ok {
+			continue
+		}
+		return g.downloadChecksums(ctx, asset)
+	}
+	return nil, nil
+}
+
+// downloadChecksums downloads and parses the uploaded checksums file.
+func (g *GHR) downloadChecksums(ctx context.Context, asset *ReleaseAsset) (map[string]string, error) {
+	rc, _, err := g.GitHub.DownloadAsset(ctx, *asset.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download checksums file: %w", err)
+	}
+	defer rc.Close()
+
+	checksums, err := ParseChecksums(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checksums file %s: %w", *asset.Name, err)
+	}
+	return checksums, nil
+}
+
+// sameAsset reports whether the local asset has the same content as the
+// uploaded asset. checksum is the hex encoded checksum of the uploaded asset
+// recorded in the checksums file and is used when the asset has no digest.
+func sameAsset(localAsset string, asset *ReleaseAsset, checksum string) (bool, error) {
+	fi, err := os.Stat(localAsset)
+	if err != nil {
+		return false, fmt.Errorf("failed to get file stat: %w", err)
+	}
+	if asset.Size == nil || int64(*asset.Size) != fi.Size() {
+		return false, nil
+	}
+
+	checksum = assetChecksum(asset, checksum)
+	algo, ok := checksumAlgorithmOf(checksum)
+	if !ok {
+		// Can not tell the content is same or not.
+		return false, nil
+	}
+
+	sum, err := FileChecksum(localAsset, algo)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(sum, checksum), nil
+}
+
+// assetChecksum returns the hex encoded checksum of the uploaded asset from
+// its digest, or checksum recorded in the checksums file when it has no digest.
+func assetChecksum(asset *ReleaseAsset, checksum string) string {
+	if sum, ok := strings.CutPrefix(asset.GetDigest(), "sha256:"); ok {
+		return sum
+	}
+	return checksum
+}
+
 // DeleteAssets removes uploaded assets for a given release
 func (g *GHR) DeleteAssets(ctx context.Context, releaseID int64, localAssets []string, parallel int) error {
 	start := time.Now()
@@ -185,6 +469,7 @@ func (g *GHR) DeleteAssets(ctx context.Context, releaseID int64, localAssets []s
 					if err := g.GitHub.DeleteAsset(ctx, *asset.ID); err != nil {
 						return fmt.Errorf("failed to delete asset: %s %w", *asset.Name, err)
 					}
+					g.journal.deleteAsset(*asset.Name)
 					return nil
 				})
 			}
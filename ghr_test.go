@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -208,7 +210,7 @@ func TestGHR_UploadAssets(t *testing.T) {
 		}
 	}()
 
-	localTestAssets, err := LocalAssets(TestDir)
+	localTestAssets, err := LocalAssets(TestDir, LocalAssetsOptions{})
 	if err != nil {
 		t.Fatal("LocalAssets failed:", err)
 	}
@@ -241,3 +243,37 @@ func TestGHR_UploadAssets(t *testing.T) {
 		t.Fatalf("upload assets number = %d, want %d", got, want)
 	}
 }
+
+func TestSameAsset(t *testing.T) {
+	localAsset := filepath.Join(TestDir, "darwin_386")
+	sum := "21fb70167c05fa4e1d4f22b6da937d73e293c974bc56dfb505425bd6be9301d9"
+	other := "d88c94dcd6c78cf35c5a04690c25a113d63c2ac68cc9aff98c413aad30fb708b"
+
+	cases := []struct {
+		asset    string
+		checksum string
+		want     bool
+	}{
+		{`{"name":"darwin_386","size":11,"digest":"sha256:` + sum + `"}`, "", true},
+		{`{"name":"darwin_386","size":11,"digest":"sha256:` + other + `"}`, sum, false},
+		{`{"name":"darwin_386","size":12,"digest":"sha256:` + sum + `"}`, "", false},
+		{`{"name":"darwin_386","size":11}`, sum, true},
+		{`{"name":"darwin_386","size":11}`, other, false},
+		{`{"name":"darwin_386","size":11}`, "", false},
+	}
+
+	for i, tc := range cases {
+		var asset ReleaseAsset
+		if err := json.Unmarshal([]byte(tc.asset), &asset); err != nil {
+			t.Fatalf("#%d Unmarshal failed: %s", i, err)
+		}
+
+		got, err := sameAsset(localAsset, &asset, tc.checksum)
+		if err != nil {
+			t.Fatalf("#%d sameAsset failed: %s", i, err)
+		}
+		if got != tc.want {
+			t.Errorf("#%d sameAsset = %t, want %t", i, got, tc.want)
+		}
+	}
+}
@@ -4,21 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
-	"time"
+	"sync"
 
-	"github.com/Songmu/retry"
 	"github.com/google/go-github/v66/github"
 	"golang.org/x/oauth2"
 )
 
-// ErrReleaseNotFound contains the error for when a release is not found
 var (
+	// ErrReleaseNotFound contains the error for when a release is not found
 	ErrReleaseNotFound = errors.New("release is not found")
+
+	// ErrMultipleDraftReleases is the error for when several draft releases
+	// have the same tag.
+	ErrMultipleDraftReleases = errors.New("multiple draft releases have the same tag")
 )
 
 // GitHub contains the functions necessary for interacting with GitHub release
@@ -28,21 +34,57 @@ type GitHub interface {
 	GetRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error)
 	GetLatestRelease(ctx context.Context) (*github.RepositoryRelease, error)
 	GetDraftRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error)
+	ListReleases(ctx context.Context) ([]*github.RepositoryRelease, error)
 	EditRelease(ctx context.Context, releaseID int64, req *github.RepositoryRelease) (*github.RepositoryRelease, error)
 	DeleteRelease(ctx context.Context, releaseID int64) error
 	DeleteTag(ctx context.Context, tag string) error
+	TagExists(ctx context.Context, tag string) (bool, error)
 
-	UploadAsset(ctx context.Context, releaseID int64, filename string) (*github.ReleaseAsset, error)
+	UploadAsset(ctx context.Context, releaseID int64, filename string, progress UploadProgress) (*github.ReleaseAsset, error)
 	DeleteAsset(ctx context.Context, assetID int64) error
-	ListAssets(ctx context.Context, releaseID int64) ([]*github.ReleaseAsset, error)
+	ListAssets(ctx context.Context, releaseID int64) ([]*ReleaseAsset, error)
+	DownloadAsset(ctx context.Context, assetID, offset int64) (io.ReadCloser, int64, error)
 
 	SetUploadURL(urlStr string) error
 }
 
+// UploadProgress receives the progress of uploading an asset.
+type UploadProgress interface {
+	// Sent is called with the number of bytes sent in the current attempt.
+	Sent(n int64)
+
+	// Retry is called when the upload is retried after err.
+	Retry(err error)
+}
+
+// ReleaseAsset is a release asset with its digest (e.g., "sha256:...") which
+// GitHub computes on upload. go-github does not decode the digest yet.
+type ReleaseAsset struct {
+	*github.ReleaseAsset
+	Digest *string `json:"digest,omitempty"`
+}
+
+// GetDigest returns the Digest field if it's non-nil, zero value otherwise.
+func (r *ReleaseAsset) GetDigest() string {
+	if r == nil || r.Digest == nil {
+		return ""
+	}
+	return *r.Digest
+}
+
 // GitHubClient is the client for interacting with the GitHub API
 type GitHubClient struct {
 	Owner, Repo string
 	*github.Client
+
+	// RetryPolicy is applied to every request to the GitHub API.
+	RetryPolicy RetryPolicy
+
+	// releases caches all releases listed in this run, since listing them
+	// takes many requests in a repository with thousands of releases. It's
+	// cleared when releases or their assets are changed.
+	mu       sync.Mutex
+	releases []*github.RepositoryRelease
 }
 
 // NewGitHubClient creates and initializes a new GitHubClient
@@ -59,6 +101,24 @@ func NewGitHubClient(owner, repo, token, urlStr string) (GitHub, error) {
 		return nil, errors.New("missing GitHub API token")
 	}
 
+	ts := oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: token,
+	})
+	return NewGitHubClientWithTokenSource(owner, repo, ts, urlStr)
+}
+
+// NewGitHubClientWithTokenSource creates a new GitHubClient which
+// authenticates with the tokens of ts, e.g., installation tokens of a GitHub
+// App.
+func NewGitHubClientWithTokenSource(owner, repo string, ts oauth2.TokenSource, urlStr string) (GitHub, error) {
+	if len(owner) == 0 {
+		return nil, errors.New("missing GitHub repository owner")
+	}
+
+	if len(repo) == 0 {
+		return nil, errors.New("missing GitHub repository name")
+	}
+
 	if len(urlStr) == 0 {
 		return nil, errors.New("missing GitHub API URL")
 	}
@@ -68,18 +128,16 @@ func NewGitHubClient(owner, repo, token, urlStr string) (GitHub, error) {
 		return nil, fmt.Errorf("failed to parse Github API URL: %w", err)
 	}
 
-	ts := oauth2.StaticTokenSource(&oauth2.Token{
-		AccessToken: token,
-	})
 	tc := oauth2.NewClient(context.TODO(), ts)
 
 	client := github.NewClient(tc)
 	client.BaseURL = baseURL
 
 	return &GitHubClient{
-		Owner:  owner,
-		Repo:   repo,
-		Client: client,
+		Owner:       owner,
+		Repo:        repo,
+		Client:      client,
+		RetryPolicy: DefaultRetryPolicy,
 	}, nil
 }
 
@@ -97,8 +155,18 @@ func (c *GitHubClient) SetUploadURL(urlStr string) error {
 
 // CreateRelease creates a new release object in the GitHub API
 func (c *GitHubClient) CreateRelease(ctx context.Context, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
-
-	release, res, err := c.Repositories.CreateRelease(context.TODO(), c.Owner, c.Repo, req)
+	defer c.clearReleases()
+
+	var (
+		release *github.RepositoryRelease
+		res     *github.Response
+	)
+	// Creating a release is not idempotent. It's retried only when GitHub
+	// rejects the request by rate limiting.
+	err := c.RetryPolicy.Do(ctx, false, func() (_ *github.Response, err error) {
+		release, res, err = c.Repositories.CreateRelease(ctx, c.Owner, c.Repo, req)
+		return res, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a release: %w", err)
 	}
@@ -113,7 +181,14 @@ func (c *GitHubClient) CreateRelease(ctx context.Context, req *github.Repository
 // GetRelease queries the GitHub API for a specified release object
 func (c *GitHubClient) GetRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
 	// Check Release whether already exists or not
-	release, res, err := c.Repositories.GetReleaseByTag(context.TODO(), c.Owner, c.Repo, tag)
+	var (
+		release *github.RepositoryRelease
+		res     *github.Response
+	)
+	err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		release, res, err = c.Repositories.GetReleaseByTag(ctx, c.Owner, c.Repo, tag)
+		return res, err
+	})
 
 	if err != nil {
 		if res == nil {
@@ -134,7 +209,14 @@ func (c *GitHubClient) GetRelease(ctx context.Context, tag string) (*github.Repo
 // GetRelease queries the GitHub API for a specified release object
 func (c *GitHubClient) GetLatestRelease(ctx context.Context) (*github.RepositoryRelease, error) {
 	// Check Release whether already exists or not
-	release, res, err := c.Repositories.GetLatestRelease(context.TODO(), c.Owner, c.Repo)
+	var (
+		release *github.RepositoryRelease
+		res     *github.Response
+	)
+	err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		release, res, err = c.Repositories.GetLatestRelease(ctx, c.Owner, c.Repo)
+		return res, err
+	})
 	if err != nil {
 		if res == nil {
 			return nil, fmt.Errorf("failed to find latest release: %w", err)
@@ -151,61 +233,117 @@ func (c *GitHubClient) GetLatestRelease(ctx context.Context) (*github.Repository
 	return release, nil
 }
 
-// GetDraftRelease queries the GitHub API for draft release with the specified tag
+// GetDraftRelease queries the GitHub API for draft release with the specified
+// tag. It scans all releases since a draft can be anywhere in the list (they
+// are listed once in a run and shared with e.g. '-latest auto'), and
+// fails with ErrMultipleDraftReleases when several drafts have the tag. It
+// returns nil when no draft has the tag.
 func (c *GitHubClient) GetDraftRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
-	const perPage = 100
-	for page := 1; page <= 2; page++ {
-		releases, res, err := c.Repositories.ListReleases(ctx, c.Owner, c.Repo, &github.ListOptions{
-			PerPage: perPage,
-			Page:    page,
-		})
-		if err != nil {
-			if res == nil {
-				return nil, fmt.Errorf("failed to get releases while getting draft release for: %s %w", tag, err)
-			}
-			// TODO(tcnksm): Handle invalid token
-			if res.StatusCode != http.StatusNotFound {
-				return nil, fmt.Errorf("get release tag: invalid status: %s %w", res.Status, err)
-			}
-			return nil, ErrReleaseNotFound
+	releases, err := c.ListReleases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get releases while getting draft release for: %s %w", tag, err)
+	}
+
+	var drafts []*github.RepositoryRelease
+	for _, rel := range releases {
+		if rel.GetDraft() && rel.GetTagName() == tag {
+			drafts = append(drafts, rel)
 		}
-		for _, rel := range releases {
-			if *rel.Draft && *rel.TagName == tag {
-				return rel, nil
-			}
+	}
+
+	switch len(drafts) {
+	case 0:
+		return nil, nil
+	case 1:
+		return drafts[0], nil
+	default:
+		ids := make([]string, 0, len(drafts))
+		for _, rel := range drafts {
+			ids = append(ids, fmt.Sprintf("%d", rel.GetID()))
 		}
-		if len(releases) < perPage {
-			break
+		return nil, fmt.Errorf("%w: %s (IDs: %s)", ErrMultipleDraftReleases, tag, strings.Join(ids, ", "))
+	}
+}
+
+// ListReleases returns all releases of the repository including drafts, newest
+// first. It follows all pages, and the result is cached until a release or an
+// asset is changed through c.
+func (c *GitHubClient) ListReleases(ctx context.Context) ([]*github.RepositoryRelease, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.releases == nil {
+		releases, err := c.listReleases(ctx)
+		if err != nil {
+			return nil, err
 		}
+		c.releases = releases
+	} else {
+		Debugf("Use %d releases listed in this run", len(c.releases))
 	}
-	return nil, nil
+	return slices.Clone(c.releases), nil
 }
 
-// EditRelease edits a release object within the GitHub API
-func (c *GitHubClient) EditRelease(ctx context.Context, releaseID int64, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
-	var release *github.RepositoryRelease
+// clearReleases clears the releases cached by ListReleases.
+func (c *GitHubClient) clearReleases() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.releases = nil
+}
 
-	err := retry.Retry(3, 3*time.Second, func() error {
+func (c *GitHubClient) listReleases(ctx context.Context) ([]*github.RepositoryRelease, error) {
+	all := []*github.RepositoryRelease{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
 		var (
-			res *github.Response
-			err error
+			releases []*github.RepositoryRelease
+			res      *github.Response
 		)
-		release, res, err = c.Repositories.EditRelease(context.TODO(), c.Owner, c.Repo, releaseID, req)
+		err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+			releases, res, err = c.Repositories.ListReleases(ctx, c.Owner, c.Repo, opts)
+			return res, err
+		})
 		if err != nil {
-			return fmt.Errorf("failed to edit release: %d %w", releaseID, err)
+			return nil, fmt.Errorf("failed to list releases: %w", err)
 		}
+		all = append(all, releases...)
 
-		if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("edit release: invalid status: %s", res.Status)
+		if res.NextPage == 0 {
+			return all, nil
 		}
-		return nil
+		opts.Page = res.NextPage
+	}
+}
+
+// EditRelease edits a release object within the GitHub API
+func (c *GitHubClient) EditRelease(ctx context.Context, releaseID int64, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	defer c.clearReleases()
+	var (
+		release *github.RepositoryRelease
+		res     *github.Response
+	)
+	err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		release, res, err = c.Repositories.EditRelease(ctx, c.Owner, c.Repo, releaseID, req)
+		return res, err
 	})
-	return release, err
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit release: %d %w", releaseID, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("edit release: invalid status: %s", res.Status)
+	}
+
+	return release, nil
 }
 
 // DeleteRelease deletes a release object within the GitHub API
 func (c *GitHubClient) DeleteRelease(ctx context.Context, releaseID int64) error {
-	res, err := c.Repositories.DeleteRelease(context.TODO(), c.Owner, c.Repo, releaseID)
+	defer c.clearReleases()
+	var res *github.Response
+	err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		res, err = c.Repositories.DeleteRelease(ctx, c.Owner, c.Repo, releaseID)
+		return res, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete release: %w", err)
 	}
@@ -220,7 +358,11 @@ func (c *GitHubClient) DeleteRelease(ctx context.Context, releaseID int64) error
 // DeleteTag deletes a tag from the GitHub API
 func (c *GitHubClient) DeleteTag(ctx context.Context, tag string) error {
 	ref := fmt.Sprintf("tags/%s", tag)
-	res, err := c.Git.DeleteRef(context.TODO(), c.Owner, c.Repo, ref)
+	var res *github.Response
+	err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		res, err = c.Git.DeleteRef(ctx, c.Owner, c.Repo, ref)
+		return res, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete tag: %s %w", ref, err)
 	}
@@ -232,55 +374,111 @@ func (c *GitHubClient) DeleteTag(ctx context.Context, tag string) error {
 	return nil
 }
 
-// UploadAsset uploads specified assets to a given release object
-func (c *GitHubClient) UploadAsset(ctx context.Context, releaseID int64, filename string) (*github.ReleaseAsset, error) {
+// TagExists reports whether the tag exists in the repository
+func (c *GitHubClient) TagExists(ctx context.Context, tag string) (bool, error) {
+	ref := fmt.Sprintf("tags/%s", tag)
+	var res *github.Response
+	err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		_, res, err = c.Git.GetRef(ctx, c.Owner, c.Repo, ref)
+		return res, err
+	})
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get tag: %s %w", ref, err)
+	}
+
+	return true, nil
+}
+
+// UploadAsset uploads specified assets to a given release object. progress,
+// if not nil, receives the progress of the upload.
+func (c *GitHubClient) UploadAsset(ctx context.Context, releaseID int64, filename string, progress UploadProgress) (*github.ReleaseAsset, error) {
+	defer c.clearReleases()
 
 	filename, err := filepath.Abs(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get abs path: %w", err)
 	}
 
-	opts := &github.UploadOptions{
-		// Use base name by default
-		Name: filepath.Base(filename),
+	// Use base name by default
+	name := filepath.Base(filename)
+	u := fmt.Sprintf("repos/%s/%s/releases/%d/assets?%s",
+		c.Owner, c.Repo, releaseID, url.Values{"name": {name}}.Encode())
+
+	mediaType := mime.TypeByExtension(filepath.Ext(filename))
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
+	defer f.Close()
 
-	var asset *github.ReleaseAsset
-	err = retry.Retry(3, 3*time.Second, func() error {
-		var (
-			res *github.Response
-			err error
-		)
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file stat: %w", err)
+	}
 
-		f, err := os.Open(filename)
-		if err != nil {
-			return fmt.Errorf("failed to open file: %w", err)
+	// Uploading is retried as before since GitHub rejects an asset which
+	// already exists with 422.
+	var (
+		asset   *github.ReleaseAsset
+		res     *github.Response
+		lastErr error
+	)
+	err = c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		if lastErr != nil && progress != nil {
+			progress.Retry(lastErr)
+		}
+		defer func() {
+			lastErr = err
+		}()
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek file: %w", err)
 		}
-		defer f.Close()
 
-		asset, res, err = c.Repositories.UploadReleaseAsset(context.TODO(), c.Owner, c.Repo, releaseID, opts, f)
+		// Request directly instead of Repositories.UploadReleaseAsset,
+		// which only takes *os.File, to report the progress.
+		var r io.Reader = f
+		if progress != nil {
+			r = &progressReader{r: f, report: progress.Sent}
+		}
+		req, err := c.NewUploadRequest(u, r, fi.Size(), mediaType)
 		if err != nil {
-			return fmt.Errorf("failed to upload release asset: %s %w", filename, err)
+			return nil, err
 		}
 
-		switch res.StatusCode {
-		case http.StatusCreated:
-			return nil
-		case 422:
-			return fmt.Errorf(
+		asset = new(github.ReleaseAsset)
+		res, err = c.Do(ctx, req, asset)
+		return res, err
+	})
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusUnprocessableEntity {
+			return nil, fmt.Errorf(
 				"upload release asset: invalid status code: %s",
 				"422 (this is probably because the asset already uploaded)")
-		default:
-			return fmt.Errorf(
-				"upload release asset: invalid status code: %s", res.Status)
 		}
-	})
-	return asset, err
+		return nil, fmt.Errorf("failed to upload release asset: %s %w", filename, err)
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf(
+			"upload release asset: invalid status code: %s", res.Status)
+	}
+
+	return asset, nil
 }
 
 // DeleteAsset deletes assets from a given release object
 func (c *GitHubClient) DeleteAsset(ctx context.Context, assetID int64) error {
-	res, err := c.Repositories.DeleteReleaseAsset(context.TODO(), c.Owner, c.Repo, assetID)
+	defer c.clearReleases()
+	var res *github.Response
+	err := c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+		res, err = c.Repositories.DeleteReleaseAsset(ctx, c.Owner, c.Repo, assetID)
+		return res, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete release asset: %w", err)
 	}
@@ -293,12 +491,28 @@ func (c *GitHubClient) DeleteAsset(ctx context.Context, assetID int64) error {
 }
 
 // ListAssets lists assets associated with a given release
-func (c *GitHubClient) ListAssets(ctx context.Context, releaseID int64) ([]*github.ReleaseAsset, error) {
-	result := []*github.ReleaseAsset{}
+func (c *GitHubClient) ListAssets(ctx context.Context, releaseID int64) ([]*ReleaseAsset, error) {
+	result := []*ReleaseAsset{}
 	page := 1
 
 	for {
-		assets, res, err := c.Repositories.ListReleaseAssets(context.TODO(), c.Owner, c.Repo, releaseID, &github.ListOptions{Page: page})
+		// Request directly instead of Repositories.ListReleaseAssets to
+		// decode the digest of assets.
+		u := fmt.Sprintf("repos/%s/%s/releases/%d/assets?page=%d", c.Owner, c.Repo, releaseID, page)
+		req, err := c.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list assets: %w", err)
+		}
+
+		var (
+			assets []*ReleaseAsset
+			res    *github.Response
+		)
+		err = c.RetryPolicy.Do(ctx, true, func() (_ *github.Response, err error) {
+			assets = nil
+			res, err = c.Do(ctx, req, &assets)
+			return res, err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list assets: %w", err)
 		}
@@ -318,3 +532,69 @@ func (c *GitHubClient) ListAssets(ctx context.Context, releaseID int64) ([]*gith
 
 	return result, nil
 }
+
+// DownloadAsset downloads the content of a release asset from offset. It
+// returns the offset where the content actually starts, which is 0 when the
+// server does not support resuming. The caller must close the returned
+// io.ReadCloser.
+func (c *GitHubClient) DownloadAsset(ctx context.Context, assetID, offset int64) (io.ReadCloser, int64, error) {
+	u := fmt.Sprintf("repos/%s/%s/releases/assets/%d", c.Owner, c.Repo, assetID)
+
+	// Assets are served from another host via redirect. Follow it with
+	// http.DefaultClient not to send the token to that host.
+	client := c.Client.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	var (
+		rc    io.ReadCloser
+		start int64
+	)
+	err := c.RetryPolicy.Do(ctx, true, func() (*github.Response, error) {
+		req, err := c.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/octet-stream")
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+
+		res, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		if loc, err := res.Location(); res.StatusCode/100 == 3 && err == nil {
+			res.Body.Close()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc.String(), nil)
+			if err != nil {
+				return nil, err
+			}
+			if offset > 0 {
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			}
+			res, err = http.DefaultClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := github.CheckResponse(res); err != nil {
+			res.Body.Close()
+			return &github.Response{Response: res}, err
+		}
+
+		rc, start = res.Body, 0
+		if res.StatusCode == http.StatusPartialContent {
+			start = offset
+		}
+		return &github.Response{Response: res}, nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download release asset: %d %w", assetID, err)
+	}
+
+	return rc, start, nil
+}
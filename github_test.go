@@ -3,10 +3,16 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -142,7 +148,7 @@ func TestGitHubClient_Upload(t *testing.T) {
 	}()
 
 	filename := filepath.Join("./testdata", "darwin_386")
-	asset, err := client.UploadAsset(context.TODO(), *release.ID, filename)
+	asset, err := client.UploadAsset(context.TODO(), *release.ID, filename, nil)
 	if err != nil {
 		t.Fatal("UploadAsset failed:", err)
 	}
@@ -204,7 +210,7 @@ func TestGitHubClient_ListAssets(t *testing.T) {
 
 	for _, filename := range []string{"darwin_386", "darwin_amd64"} {
 		filename := filepath.Join("./testdata", filename)
-		if _, err := client.UploadAsset(context.TODO(), *release.ID, filename); err != nil {
+		if _, err := client.UploadAsset(context.TODO(), *release.ID, filename, nil); err != nil {
 			t.Fatal("UploadAsset failed:", err)
 		}
 	}
@@ -231,3 +237,121 @@ func TestGitHubClient_ListAssets(t *testing.T) {
 		t.Fatalf("ListAssets number = %d, want %d", got, want)
 	}
 }
+
+func TestGitHubClient_GetDraftRelease(t *testing.T) {
+	// 250 releases in 3 pages. Drafts of v1.0.0 are on the last page.
+	var releases []*github.RepositoryRelease
+	for i := 1; i <= 250; i++ {
+		releases = append(releases, &github.RepositoryRelease{
+			ID:      github.Int64(int64(i)),
+			TagName: github.String(fmt.Sprintf("nightly-%d", i)),
+		})
+	}
+	draft := func(id int64, tag string) *github.RepositoryRelease {
+		return &github.RepositoryRelease{ID: github.Int64(id), TagName: github.String(tag), Draft: github.Bool(true)}
+	}
+	releases[220] = draft(221, "v1.0.0")
+	releases[240] = draft(241, "v1.1.0")
+	releases[241] = draft(242, "v1.1.0")
+
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/tcnksm/ghr/releases", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		start, end := (page-1)*100, min(page*100, len(releases))
+		if end < len(releases) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+		}
+		json.NewEncoder(w).Encode(releases[start:end])
+	})
+	mux.HandleFunc("DELETE /repos/tcnksm/ghr/releases/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewGitHubClient("tcnksm", "ghr", "token", server.URL+"/")
+	if err != nil {
+		t.Fatal("NewGitHubClient failed:", err)
+	}
+
+	release, err := client.GetDraftRelease(context.TODO(), "v1.0.0")
+	if err != nil {
+		t.Fatal("GetDraftRelease failed:", err)
+	}
+	if release.GetID() != 221 {
+		t.Fatalf("GetDraftRelease returns %d; want 221", release.GetID())
+	}
+
+	release, err = client.GetDraftRelease(context.TODO(), "v2.0.0")
+	if err != nil || release != nil {
+		t.Fatalf("GetDraftRelease = %v, %v; want nil", release, err)
+	}
+
+	_, err = client.GetDraftRelease(context.TODO(), "v1.1.0")
+	if !errors.Is(err, ErrMultipleDraftReleases) {
+		t.Fatalf("expect GetDraftRelease to fail with ErrMultipleDraftReleases: %v", err)
+	}
+
+	// The releases are listed once in a run.
+	if _, err := client.ListReleases(context.TODO()); err != nil {
+		t.Fatal("ListReleases failed:", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("ListReleases requests %d pages; want 3", got)
+	}
+
+	// They're listed again after a release is changed.
+	if err := client.DeleteRelease(context.TODO(), 1); err != nil {
+		t.Fatal("DeleteRelease failed:", err)
+	}
+	if _, err := client.GetDraftRelease(context.TODO(), "v1.0.0"); err != nil {
+		t.Fatal("GetDraftRelease failed:", err)
+	}
+	if got := requests.Load(); got != 6 {
+		t.Fatalf("ListReleases requests %d pages; want 6", got)
+	}
+}
+
+func TestGitHubClient_DownloadAsset(t *testing.T) {
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/tcnksm/ghr/releases/assets/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/storage/1", http.StatusFound)
+	})
+	mux.HandleFunc("/storage/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ghr")
+	})
+	mux.HandleFunc("/repos/tcnksm/ghr/releases/assets/2", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewGitHubClient("tcnksm", "ghr", "token", server.URL+"/")
+	if err != nil {
+		t.Fatal("NewGitHubClient failed:", err)
+	}
+
+	rc, _, err := client.DownloadAsset(context.TODO(), 1, 0)
+	if err != nil {
+		t.Fatal("DownloadAsset failed:", err)
+	}
+	defer rc.Close()
+	if got, _ := io.ReadAll(rc); string(got) != "ghr" {
+		t.Fatalf("DownloadAsset reads %q; want ghr", got)
+	}
+
+	// The response is passed to the retry policy, so 404 is not retried.
+	if _, _, err := client.DownloadAsset(context.TODO(), 2, 0); err == nil {
+		t.Fatal("expect DownloadAsset to fail")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("DownloadAsset requests %d times; want 1", got)
+	}
+}
@@ -3,23 +3,24 @@ module github.com/tcnksm/ghr
 go 1.26.0
 
 require (
-	github.com/Songmu/retry v0.1.0
+	github.com/BurntSushi/toml v1.6.0
 	github.com/google/go-github/v66 v66.0.0
 	github.com/hashicorp/go-version v1.9.0
 	github.com/mattn/go-colorable v0.1.14
+	github.com/mattn/go-isatty v0.0.21
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db
 	github.com/tcnksm/go-gitconfig v0.1.2
 	github.com/tcnksm/go-latest v0.0.0-20170313132115-e3007ae9052e
 	github.com/thediveo/enumflag/v2 v2.2.0
 	golang.org/x/oauth2 v0.36.0
 	golang.org/x/sync v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/google/go-github v17.0.0+incompatible // indirect
 	github.com/google/go-querystring v1.2.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/mattn/go-isatty v0.0.21 // indirect
 	github.com/spf13/cobra v1.10.2 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	golang.org/x/net v0.55.0 // indirect
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// journal records objects changed on GitHub in this run so that the ones
+// created in this run can be rolled back when ghr fails.
+type journal struct {
+	mu sync.Mutex
+
+	// release is the release created in this run, if any.
+	release *github.RepositoryRelease
+
+	// tagCreated reports whether the tag of the release did not exist
+	// before this run, so that the tag is deleted with the release.
+	tagCreated bool
+
+	// recreated is the existing release deleted by -recreate, if any.
+	recreated *github.RepositoryRelease
+
+	// uploaded and deleted are assets uploaded and deleted in this run.
+	uploaded []*github.ReleaseAsset
+	deleted  []string
+}
+
+func (j *journal) createRelease(release *github.RepositoryRelease, tagCreated bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.release = release
+	j.tagCreated = tagCreated
+}
+
+func (j *journal) recreateRelease(release *github.RepositoryRelease) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.recreated = release
+}
+
+func (j *journal) uploadAsset(asset *github.ReleaseAsset) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.uploaded = append(j.uploaded, asset)
+}
+
+func (j *journal) deleteAsset(name string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.deleted = append(j.deleted, name)
+}
+
+// empty reports whether nothing is changed in this run.
+func (j *journal) empty() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.release == nil && j.recreated == nil && len(j.uploaded) == 0 && len(j.deleted) == 0
+}
+
+// Rollback deletes the objects created in this run. When the release is
+// created in this run, it's deleted with its assets, and with its tag if the
+// tag did not exist before this run. Otherwise the assets uploaded to the
+// existing release in this run are deleted, except the ones replacing assets
+// deleted in this run (by -replace or -skip-existing), which can not be
+// restored.
+//
+// When the release replaces the one deleted by -recreate, it's the only copy
+// of the release, so it's kept with its assets instead.
+func (g *GHR) Rollback(ctx context.Context) error {
+	j := &g.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.recreated != nil {
+		fmt.Fprintf(g.outStream, "WARNING: release %s (ID: %d) was deleted by '-recreate' and can not be restored\n",
+			j.recreated.GetTagName(), j.recreated.GetID())
+		if j.release != nil {
+			fmt.Fprintf(g.outStream, "WARNING: keep release %s (ID: %d) created in this run with %d uploaded assets\n",
+				j.release.GetTagName(), j.release.GetID(), len(j.uploaded))
+		}
+		j.release, j.recreated, j.uploaded, j.deleted = nil, nil, nil, nil
+		return nil
+	}
+
+	fmt.Fprintln(g.outStream, "==> Roll back objects created in this run")
+
+	var errs []error
+	if j.release != nil {
+		if err := g.GitHub.DeleteRelease(ctx, j.release.GetID()); err != nil {
+			errs = append(errs, err)
+		} else {
+			fmt.Fprintf(g.outStream, "--> Deleted release: %s (with %d uploaded assets)\n",
+				j.release.GetTagName(), len(j.uploaded))
+			if j.tagCreated {
+				if err := g.deleteCreatedTag(ctx, j.release.GetTagName()); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			j.release, j.uploaded = nil, nil
+		}
+	} else {
+		replaced := map[string]bool{}
+		for _, name := range j.deleted {
+			replaced[name] = true
+		}
+
+		var remaining []*github.ReleaseAsset
+		for _, asset := range j.uploaded {
+			// The asset replaces the one deleted in this run, so deleting
+			// it would leave the release without the asset.
+			if replaced[asset.GetName()] {
+				fmt.Fprintf(g.outStream, "WARNING: keep asset %s replacing the one deleted in this run\n", asset.GetName())
+				delete(replaced, asset.GetName())
+				continue
+			}
+			if err := g.GitHub.DeleteAsset(ctx, asset.GetID()); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete asset: %s %w", asset.GetName(), err))
+				remaining = append(remaining, asset)
+				continue
+			}
+			fmt.Fprintf(g.outStream, "--> Deleted asset: %15s\n", asset.GetName())
+		}
+		j.uploaded = remaining
+
+		for _, name := range j.deleted {
+			if replaced[name] {
+				fmt.Fprintf(g.outStream, "WARNING: asset %s was deleted in this run and can not be restored\n", name)
+			}
+		}
+		j.deleted = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// deleteCreatedTag deletes the tag created in this run, if any. GitHub
+// creates the tag of a release only when it's published, so the tag may not
+// exist even if the release is created.
+func (g *GHR) deleteCreatedTag(ctx context.Context, tag string) error {
+	exists, err := g.GitHub.TagExists(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	if err := g.GitHub.DeleteTag(ctx, tag); err != nil {
+		return err
+	}
+	fmt.Fprintf(g.outStream, "--> Deleted tag: %s\n", tag)
+	return nil
+}
+
+// Abort handles the objects created in this run when ghr fails. They're
+// kept and printed with keepPartial or KeepGoing, since -keep-going uploads
+// as many assets as possible and a failure of one of them should not delete
+// the others. Otherwise they're rolled back.
+func (g *GHR) Abort(ctx context.Context, keepPartial bool) error {
+	if keepPartial || g.KeepGoing {
+		g.PrintPartial()
+		return nil
+	}
+	return g.Rollback(ctx)
+}
+
+// PrintPartial prints the objects created in this run which are kept as they
+// are after a failure.
+func (g *GHR) PrintPartial() {
+	j := &g.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	fmt.Fprintln(g.outStream, "==> Keep objects created in this run")
+	if j.recreated != nil {
+		fmt.Fprintf(g.outStream, "--> Deleted release: %s (ID: %d)\n", j.recreated.GetTagName(), j.recreated.GetID())
+	}
+	if j.release != nil {
+		fmt.Fprintf(g.outStream, "--> Draft release: %s (ID: %d)\n", j.release.GetTagName(), j.release.GetID())
+	}
+	for _, asset := range j.uploaded {
+		fmt.Fprintf(g.outStream, "--> Uploaded asset: %15s\n", asset.GetName())
+	}
+	for _, name := range j.deleted {
+		fmt.Fprintf(g.outStream, "--> Deleted asset: %15s\n", name)
+	}
+}
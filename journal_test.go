@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestGHR_Rollback(t *testing.T) {
+	localAssets := []string{
+		filepath.Join(TestDir, "darwin_386"),
+		filepath.Join(TestDir, "linux_386"),
+	}
+
+	cases := []struct {
+		create bool
+		want   []string
+	}{
+		// 0: When the release is created in this run, it's deleted.
+		{
+			true,
+			[]string{
+				"[dry-run] delete release (new)",
+				"--> Deleted release: v1.0.0 (with 2 uploaded assets)",
+			},
+		},
+
+		// 1: When the existing release is used, uploaded assets are deleted.
+		{
+			false,
+			[]string{
+				"--> Deleted asset:      darwin_386",
+				"--> Deleted asset:       linux_386",
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		var buf bytes.Buffer
+		ghr := &GHR{
+			GitHub:    &dryRunGitHub{GitHub: &tagsGitHub{}, outStream: &buf},
+			outStream: &buf,
+		}
+		ctx := context.TODO()
+
+		releaseID := int64(-100)
+		if tc.create {
+			release, err := ghr.CreateRelease(ctx, &github.RepositoryRelease{
+				TagName: github.String("v1.0.0"),
+				Draft:   github.Bool(true),
+			}, false)
+			if err != nil {
+				t.Fatalf("#%d CreateRelease failed: %s", i, err)
+			}
+			releaseID = *release.ID
+		}
+
+		if err := ghr.UploadAssets(ctx, releaseID, localAssets, 1); err != nil {
+			t.Fatalf("#%d UploadAssets failed: %s", i, err)
+		}
+
+		if ghr.journal.empty() {
+			t.Fatalf("#%d journal is empty", i)
+		}
+
+		if err := ghr.Rollback(ctx); err != nil {
+			t.Fatalf("#%d Rollback failed: %s", i, err)
+		}
+
+		for _, want := range tc.want {
+			if got := buf.String(); !strings.Contains(got, want) {
+				t.Errorf("#%d Rollback outputs %q, want %q", i, got, want)
+			}
+		}
+
+		if !ghr.journal.empty() {
+			t.Errorf("#%d journal is not empty after Rollback", i)
+		}
+	}
+}
+
+// tagsGitHub is a fake GitHub which has tags and records deleted objects.
+type tagsGitHub struct {
+	GitHub
+
+	tags    map[string]bool
+	deleted []string
+}
+
+func (g *tagsGitHub) TagExists(ctx context.Context, tag string) (bool, error) {
+	return g.tags[tag], nil
+}
+
+func (g *tagsGitHub) CreateRelease(ctx context.Context, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	return &github.RepositoryRelease{ID: github.Int64(2), TagName: req.TagName}, nil
+}
+
+func (g *tagsGitHub) DeleteRelease(ctx context.Context, releaseID int64) error {
+	g.deleted = append(g.deleted, fmt.Sprintf("release %d", releaseID))
+	return nil
+}
+
+func (g *tagsGitHub) DeleteAsset(ctx context.Context, assetID int64) error {
+	g.deleted = append(g.deleted, fmt.Sprintf("asset %d", assetID))
+	return nil
+}
+
+func (g *tagsGitHub) DeleteTag(ctx context.Context, tag string) error {
+	g.deleted = append(g.deleted, "tag "+tag)
+	return nil
+}
+
+func TestGHR_Rollback_tag(t *testing.T) {
+	cases := []struct {
+		before, after bool
+		want          []string
+	}{
+		// 0: The tag created in this run is deleted.
+		{false, true, []string{"release 2", "tag v1.0.0"}},
+
+		// 1: The tag which exists before this run is kept.
+		{true, true, []string{"release 2"}},
+
+		// 2: The tag is not created since the release is not published.
+		{false, false, []string{"release 2"}},
+	}
+
+	for i, tc := range cases {
+		gh := &tagsGitHub{tags: map[string]bool{"v1.0.0": tc.before}}
+		ghr := &GHR{GitHub: gh, outStream: io.Discard}
+		ctx := context.TODO()
+
+		if _, err := ghr.createRelease(ctx, &github.RepositoryRelease{TagName: github.String("v1.0.0")}); err != nil {
+			t.Fatalf("#%d createRelease failed: %s", i, err)
+		}
+		gh.tags["v1.0.0"] = tc.after
+
+		if err := ghr.Rollback(ctx); err != nil {
+			t.Fatalf("#%d Rollback failed: %s", i, err)
+		}
+		if !reflect.DeepEqual(gh.deleted, tc.want) {
+			t.Errorf("#%d Rollback deletes %q, want %q", i, gh.deleted, tc.want)
+		}
+	}
+}
+
+func TestGHR_Rollback_recreate(t *testing.T) {
+	var buf bytes.Buffer
+	gh := &tagsGitHub{}
+	ghr := &GHR{GitHub: gh, outStream: &buf}
+	ctx := context.TODO()
+
+	ghr.journal.recreateRelease(&github.RepositoryRelease{ID: github.Int64(1), TagName: github.String("v1.0.0")})
+	if _, err := ghr.createRelease(ctx, &github.RepositoryRelease{TagName: github.String("v1.0.0")}); err != nil {
+		t.Fatal("createRelease failed:", err)
+	}
+
+	if err := ghr.Rollback(ctx); err != nil {
+		t.Fatal("Rollback failed:", err)
+	}
+
+	// The release created in this run is the only copy, so it's kept.
+	if len(gh.deleted) != 0 {
+		t.Errorf("Rollback deletes %q, want none", gh.deleted)
+	}
+	for _, want := range []string{
+		"WARNING: release v1.0.0 (ID: 1) was deleted by '-recreate'",
+		"WARNING: keep release v1.0.0 (ID: 2) created in this run",
+	} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("Rollback outputs %q, want %q", got, want)
+		}
+	}
+	if !ghr.journal.empty() {
+		t.Error("journal is not empty after Rollback")
+	}
+}
+
+func TestGHR_Rollback_replace(t *testing.T) {
+	var buf bytes.Buffer
+	gh := &tagsGitHub{}
+	ghr := &GHR{GitHub: gh, outStream: &buf}
+
+	// darwin_386 is replaced by -replace, and linux_386 is a new asset.
+	ghr.journal.deleteAsset("darwin_386")
+	ghr.journal.uploadAsset(&github.ReleaseAsset{ID: github.Int64(1), Name: github.String("darwin_386")})
+	ghr.journal.uploadAsset(&github.ReleaseAsset{ID: github.Int64(2), Name: github.String("linux_386")})
+	ghr.journal.deleteAsset("windows_386")
+
+	if err := ghr.Rollback(context.TODO()); err != nil {
+		t.Fatal("Rollback failed:", err)
+	}
+
+	// The replacement is the only copy of the asset, so it's kept.
+	if want := []string{"asset 2"}; !reflect.DeepEqual(gh.deleted, want) {
+		t.Errorf("Rollback deletes %q, want %q", gh.deleted, want)
+	}
+	for _, want := range []string{
+		"WARNING: keep asset darwin_386 replacing the one deleted in this run",
+		"WARNING: asset windows_386 was deleted in this run and can not be restored",
+	} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("Rollback outputs %q, want %q", got, want)
+		}
+	}
+	if got := buf.String(); strings.Contains(got, "darwin_386 was deleted") {
+		t.Errorf("Rollback outputs %q, want no warning for the replaced asset", got)
+	}
+	if !ghr.journal.empty() {
+		t.Error("journal is not empty after Rollback")
+	}
+}
+
+func TestGHR_PrintPartial(t *testing.T) {
+	var buf bytes.Buffer
+	ghr := &GHR{
+		GitHub:    &dryRunGitHub{outStream: io.Discard},
+		outStream: &buf,
+	}
+
+	ghr.journal.createRelease(&github.RepositoryRelease{
+		ID:      github.Int64(1),
+		TagName: github.String("v1.0.0"),
+	}, false)
+	ghr.journal.uploadAsset(&github.ReleaseAsset{Name: github.String("darwin_386")})
+	ghr.PrintPartial()
+
+	for _, want := range []string{"--> Draft release: v1.0.0 (ID: 1)", "--> Uploaded asset:      darwin_386"} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("PrintPartial outputs %q, want %q", got, want)
+		}
+	}
+}
+
+func TestGHR_Abort(t *testing.T) {
+	cases := []struct {
+		keepPartial, keepGoing bool
+		want                   string
+	}{
+		{false, false, "==> Roll back objects created in this run"},
+		{true, false, "==> Keep objects created in this run"},
+
+		// -keep-going does not delete the assets uploaded successfully.
+		{false, true, "==> Keep objects created in this run"},
+	}
+
+	for i, tc := range cases {
+		var buf bytes.Buffer
+		ghr := &GHR{
+			GitHub:    &dryRunGitHub{outStream: io.Discard},
+			KeepGoing: tc.keepGoing,
+			outStream: &buf,
+		}
+		ghr.journal.uploadAsset(&github.ReleaseAsset{ID: github.Int64(1), Name: github.String("darwin_386")})
+
+		if err := ghr.Abort(context.TODO(), tc.keepPartial); err != nil {
+			t.Fatalf("#%d Abort failed: %s", i, err)
+		}
+		if got := buf.String(); !strings.Contains(got, tc.want) {
+			t.Errorf("#%d Abort outputs %q, want %q", i, got, tc.want)
+		}
+	}
+}
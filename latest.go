@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// IsLatestRelease decides whether the release of tag should be marked as the
+// latest one with '-latest auto'. Tags are compared as semver after removing
+// tagPrefix (e.g., "cli/" for "cli/v1.2.3" in a monorepo), and releases
+// whose tag does not have the prefix are ignored.
+//
+// A prerelease, either by the flag or by the version (e.g., v1.2.0-rc.1), is
+// never the latest. Otherwise the release is the latest when its version is
+// higher than every published stable release, including when it's the first
+// one.
+func (g *GHR) IsLatestRelease(ctx context.Context, tag, tagPrefix string, prerelease bool) (bool, error) {
+	if prerelease {
+		Debugf("Latest: %s is a prerelease", tag)
+		return false, nil
+	}
+
+	newVer, err := tagVersion(tag, tagPrefix)
+	if err != nil {
+		return false, err
+	}
+	if newVer.Prerelease() != "" {
+		Debugf("Latest: %s has a prerelease version", tag)
+		return false, nil
+	}
+
+	releases, err := g.GitHub.ListReleases(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var highest *version.Version
+	for _, release := range releases {
+		// The release itself, e.g., with -replace.
+		if release.GetDraft() || release.GetPrerelease() || release.GetTagName() == tag {
+			continue
+		}
+		v, err := tagVersion(release.GetTagName(), tagPrefix)
+		if err != nil {
+			Debugf("Latest: ignore %s: %s", release.GetTagName(), err)
+			continue
+		}
+		if v.Prerelease() != "" {
+			continue
+		}
+		if highest == nil || v.GreaterThan(highest) {
+			highest = v
+		}
+	}
+
+	if highest == nil {
+		Debugf("Latest: %s is the first stable release", tag)
+		return true, nil
+	}
+	Debugf("Latest: compare %s with the highest stable version %s", newVer, highest)
+	return newVer.GreaterThan(highest), nil
+}
+
+// tagVersion parses the tag without the prefix as a semver.
+func tagVersion(tag, prefix string) (*version.Version, error) {
+	s, ok := strings.CutPrefix(tag, prefix)
+	if !ok {
+		return nil, fmt.Errorf("tag %s does not have the prefix %q", tag, prefix)
+	}
+	v, err := version.NewSemver(s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse tag %s as semver: %w", tag, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestGHR_IsLatestRelease(t *testing.T) {
+	release := func(tag string, draft, prerelease bool) *github.RepositoryRelease {
+		return &github.RepositoryRelease{
+			TagName:    github.String(tag),
+			Draft:      github.Bool(draft),
+			Prerelease: github.Bool(prerelease),
+		}
+	}
+	releases := []*github.RepositoryRelease{
+		release("v2.0.0-rc.1", false, false),
+		release("v1.9.0", true, false),
+		release("v1.8.0", false, true),
+		release("nightly", false, false),
+		release("v1.2.0", false, false),
+		release("v1.10.0", false, false),
+		release("cli/v0.3.0", false, false),
+	}
+
+	cases := []struct {
+		releases   []*github.RepositoryRelease
+		tag        string
+		prefix     string
+		prerelease bool
+		want       bool
+	}{
+		// 0: Higher than the highest stable release
+		{releases, "v1.11.0", "", false, true},
+
+		// 1: Lower than the highest stable release, not only the one
+		// listed first.
+		{releases, "v1.9.0", "", false, false},
+
+		// 2: Prereleases never become latest.
+		{releases, "v2.0.0", "", true, false},
+		{releases, "v2.0.0-rc.2", "", false, false},
+
+		// 4: The first release
+		{nil, "v0.1.0", "", false, true},
+
+		// 5: Releases with the prefix
+		{releases, "cli/v0.4.0", "cli/", false, true},
+		{releases, "cli/v0.2.0", "cli/", false, false},
+
+		// 7: The release itself is not compared.
+		{releases, "v1.10.0", "", false, true},
+	}
+
+	for i, tc := range cases {
+		ghr := &GHR{GitHub: &releasesGitHub{releases: tc.releases}}
+		got, err := ghr.IsLatestRelease(context.TODO(), tc.tag, tc.prefix, tc.prerelease)
+		if err != nil {
+			t.Fatalf("#%d IsLatestRelease failed: %s", i, err)
+		}
+		if got != tc.want {
+			t.Fatalf("#%d IsLatestRelease = %t; want %t", i, got, tc.want)
+		}
+	}
+
+	ghr := &GHR{GitHub: &releasesGitHub{releases: releases}}
+	for _, tag := range []string{"nightly", "v1.0.0"} {
+		if _, err := ghr.IsLatestRelease(context.TODO(), tag, "cli/", false); err == nil {
+			t.Fatalf("expect IsLatestRelease to fail for %s", tag)
+		}
+	}
+}
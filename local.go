@@ -1,17 +1,44 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
+// LocalAssetsOptions controls which files LocalAssets picks up from a
+// directory.
+type LocalAssetsOptions struct {
+	// Recursive walks into subdirectories instead of only looking at the
+	// top level of the directory.
+	Recursive bool
+
+	// Include and Exclude are glob patterns matched against the slash
+	// separated path relative to the directory. `**` matches any number of
+	// directories. A pattern without a slash is matched against the base
+	// name at any depth. When Include is empty, every non-hidden file is a
+	// candidate. Hidden files and directories are included only by a
+	// pattern whose segment for them starts with '.', e.g., `.github/**`.
+	Include []string
+	Exclude []string
+}
+
 // LocalAssets contains the local objects to be uploaded
-func LocalAssets(path string) ([]string, error) {
+func LocalAssets(path string, opts LocalAssetsOptions) ([]string, error) {
 	if path == "" {
 		return []string{}, nil
 	}
 
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if err := validatePattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+
 	path, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get abs path: %w", err)
@@ -26,27 +53,200 @@ func LocalAssets(path string) ([]string, error) {
 		return []string{path}, nil
 	}
 
-	// Glob all files in the given path
-	files, err := filepath.Glob(filepath.Join(path, "*"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to glob files: %w", err)
-	}
+	assets := []string{}
+	names := map[string]string{}
+	err = filepath.WalkDir(path, func(f string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if f == path {
+			return nil
+		}
 
-	assets := make([]string, 0, len(files))
-	for _, f := range files {
+		rel, err := filepath.Rel(path, f)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
 
-		// Exclude directory.
-		if fi, _ := os.Stat(f); fi.IsDir() {
-			continue
+		// Exclude hidden file and directory unless the user asked for
+		// them explicitly.
+		hidden := isHidden(d.Name())
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			if fi, err := os.Stat(f); err == nil && fi.IsDir() {
+				// Do not follow symlinked directories.
+				return nil
+			}
 		}
 
-		// Exclude hidden file
-		if filepath.Base(f)[0] == '.' {
-			continue
+		if d.IsDir() {
+			if !opts.Recursive || (hidden && !matchAnyDir(opts.Include, rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if len(opts.Include) == 0 {
+			if hidden {
+				return nil
+			}
+		} else if !matchAny(opts.Include, rel, true) {
+			return nil
 		}
+		if matchAny(opts.Exclude, rel, false) {
+			return nil
+		}
+
+		// Uploaded asset name is same as basename of local file
+		name := filepath.Base(f)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("asset name %q is used by both %s and %s", name, other, rel)
+		}
+		names[name] = rel
 
 		assets = append(assets, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk files: %w", err)
 	}
 
 	return assets, nil
 }
+
+func validatePattern(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// matchAny reports whether rel matches any of the patterns. With
+// explicitHidden, a hidden segment of rel matches only a pattern segment
+// starting with '.', and `**` does not match hidden directories.
+func matchAny(patterns []string, rel string, explicitHidden bool) bool {
+	for _, pattern := range patterns {
+		if matchSegments(patternSegments(pattern), strings.Split(rel, "/"), explicitHidden, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyDir reports whether files in the directory rel may match any of
+// the patterns, with hidden segments matched as in matchAny with
+// explicitHidden.
+func matchAnyDir(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchSegments(patternSegments(pattern), strings.Split(rel, "/"), true, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternSegments splits the pattern by '/'. A pattern without a slash is
+// matched against the base name at any depth, i.e., it's `**/PATTERN`.
+func patternSegments(pattern string) []string {
+	if !strings.Contains(pattern, "/") {
+		return []string{"**", pattern}
+	}
+	return strings.Split(pattern, "/")
+}
+
+// matchSegments reports whether the segments of a slash separated relative
+// path match the pattern. `**` as a whole segment matches zero or more
+// directories. With prefix, it reports whether name can be the leading
+// directories of a path matching the pattern.
+func matchSegments(pattern, name []string, explicitHidden, prefix bool) bool {
+	for len(pattern) > 0 {
+		if prefix && len(name) == 0 {
+			return true
+		}
+
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:], explicitHidden, prefix) {
+					return true
+				}
+				if i < len(name) && explicitHidden && isHidden(name[i]) {
+					return false
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if explicitHidden && isHidden(name[0]) && !isHidden(pattern[0]) {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// maxAssetSize is the size limit of a single release asset on GitHub.
+const maxAssetSize = 2 << 30
+
+// ValidateAssets checks local assets for problems which would make the upload
+// fail (or behave unexpectedly) after the release is already created. It
+// returns all problems found joined into one error.
+func ValidateAssets(localAssets []string) error {
+	var errs []error
+	names := map[string]string{}
+	for _, localAsset := range localAssets {
+		name := filepath.Base(localAsset)
+
+		if renamed := githubAssetName(name); renamed != name {
+			errs = append(errs, fmt.Errorf("%s: GitHub renames the asset to %q", localAsset, renamed))
+		}
+
+		if other, ok := names[githubAssetName(name)]; ok {
+			errs = append(errs, fmt.Errorf("%s: asset name %q conflicts with %s", localAsset, name, other))
+		} else {
+			names[githubAssetName(name)] = localAsset
+		}
+
+		fi, err := os.Stat(localAsset)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get file stat: %w", err))
+			continue
+		}
+		switch {
+		case fi.Size() == 0:
+			errs = append(errs, fmt.Errorf("%s: file is empty", localAsset))
+		case fi.Size() >= maxAssetSize:
+			errs = append(errs, fmt.Errorf("%s: file size %d exceeds the 2 GiB limit", localAsset, fi.Size()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// githubAssetName returns the name GitHub gives to an uploaded asset: characters
+// other than ASCII letters, digits, '-', '_', '+' and '.' are replaced with '.'
+// and leading or trailing periods are removed.
+func githubAssetName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9',
+			r == '-', r == '_', r == '+', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('.')
+		}
+	}
+	return strings.Trim(b.String(), ".")
+}
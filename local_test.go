@@ -1,13 +1,19 @@
 package main
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 const (
 	TestDir = "./testdata"
 )
 
 func TestLocalAssets(t *testing.T) {
-	localAssets, err := LocalAssets(TestDir)
+	localAssets, err := LocalAssets(TestDir, LocalAssetsOptions{})
 	if err != nil {
 		t.Fatal("LocalAssets failed:", err)
 	}
@@ -18,7 +24,7 @@ func TestLocalAssets(t *testing.T) {
 }
 
 func TestLocalEmptyAssets(t *testing.T) {
-	localAssets, err := LocalAssets("")
+	localAssets, err := LocalAssets("", LocalAssetsOptions{})
 	if err != nil {
 		t.Fatal("LocalAssets failed:", err)
 	}
@@ -27,3 +33,158 @@ func TestLocalEmptyAssets(t *testing.T) {
 		t.Fatalf("localAssets number = %d, want %d", got, want)
 	}
 }
+
+func TestLocalAssets_patterns(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"foo.txt",
+		".hidden",
+		"linux/amd64/foo",
+		"linux/amd64/foo.tar.gz",
+		"darwin/arm64/bar.tar.gz",
+		".cache/baz.tar.gz",
+		".git/objects/qux.tar.gz",
+	} {
+		f := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		opts LocalAssetsOptions
+		want []string
+	}{
+		{
+			LocalAssetsOptions{},
+			[]string{"foo.txt"},
+		},
+		{
+			LocalAssetsOptions{Recursive: true},
+			[]string{"darwin/arm64/bar.tar.gz", "foo.txt", "linux/amd64/foo", "linux/amd64/foo.tar.gz"},
+		},
+		{
+			LocalAssetsOptions{Recursive: true, Include: []string{"*.tar.gz"}},
+			[]string{"darwin/arm64/bar.tar.gz", "linux/amd64/foo.tar.gz"},
+		},
+		{
+			LocalAssetsOptions{Recursive: true, Include: []string{"*"}},
+			[]string{"darwin/arm64/bar.tar.gz", "foo.txt", "linux/amd64/foo", "linux/amd64/foo.tar.gz"},
+		},
+		{
+			LocalAssetsOptions{Recursive: true, Include: []string{".cache/*", ".hidden"}, Exclude: []string{"foo.txt"}},
+			[]string{".cache/baz.tar.gz", ".hidden"},
+		},
+		{
+			LocalAssetsOptions{Recursive: true, Include: []string{".*/**/*.tar.gz"}, Exclude: []string{"*/objects/*"}},
+			[]string{".cache/baz.tar.gz"},
+		},
+		{
+			LocalAssetsOptions{Recursive: true, Include: []string{"linux/**"}},
+			[]string{"linux/amd64/foo", "linux/amd64/foo.tar.gz"},
+		},
+		{
+			LocalAssetsOptions{Recursive: true, Include: []string{"**/*.tar.gz", ".cache/*"}, Exclude: []string{".cache/**", "darwin/*/*"}},
+			[]string{"linux/amd64/foo.tar.gz"},
+		},
+	}
+
+	for i, tc := range cases {
+		assets, err := LocalAssets(dir, tc.opts)
+		if err != nil {
+			t.Fatalf("#%d LocalAssets failed: %s", i, err)
+		}
+
+		got := make([]string, 0, len(assets))
+		for _, a := range assets {
+			rel, _ := filepath.Rel(dir, a)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("#%d LocalAssets = %v, want %v", i, got, tc.want)
+		}
+	}
+}
+
+func TestLocalAssets_duplicateName(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"linux/ghr", "darwin/ghr"} {
+		f := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := LocalAssets(dir, LocalAssetsOptions{Recursive: true})
+	if err == nil || !strings.Contains(err.Error(), `asset name "ghr"`) {
+		t.Fatalf("LocalAssets error = %v, want duplicate asset name error", err)
+	}
+}
+
+func TestLocalAssets_invalidPattern(t *testing.T) {
+	_, err := LocalAssets(TestDir, LocalAssetsOptions{Include: []string{"[a-"}})
+	if err == nil {
+		t.Fatal("LocalAssets expects error for invalid pattern")
+	}
+}
+
+func TestValidateAssets(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		f := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	cases := []struct {
+		assets []string
+		want   []string
+	}{
+		{
+			[]string{write("ghr_linux_amd64.tar.gz", "ghr"), write("ghr+v1.0.0_SHA256SUMS", "ghr")},
+			nil,
+		},
+		{
+			[]string{write("empty", "")},
+			[]string{"file is empty"},
+		},
+		{
+			[]string{write("with space", "ghr"), write(".hidden", "ghr"), write("日本語", "ghr")},
+			[]string{`renames the asset to "with.space"`, `renames the asset to "hidden"`, `renames the asset to ""`},
+		},
+		{
+			[]string{write("a/ghr", "ghr"), write("b/ghr", "ghr")},
+			[]string{`asset name "ghr" conflicts with`},
+		},
+	}
+
+	for i, tc := range cases {
+		err := ValidateAssets(tc.assets)
+		if len(tc.want) == 0 {
+			if err != nil {
+				t.Errorf("#%d ValidateAssets failed: %s", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("#%d ValidateAssets expects error", i)
+			continue
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("#%d ValidateAssets error %q, want %q", i, err, want)
+			}
+		}
+	}
+}
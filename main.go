@@ -8,6 +8,7 @@ import (
 
 func main() {
 	cli := &CLI{
+		inStream:  os.Stdin,
 		outStream: colorable.NewColorableStdout(),
 		errStream: colorable.NewColorableStderr(),
 	}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/thediveo/enumflag/v2"
+)
+
+type OutputFormat enumflag.Flag
+
+const (
+	outputText OutputFormat = iota
+	outputJSON
+)
+
+var OutputFormatIds = map[OutputFormat][]string{
+	outputText: {"text"},
+	outputJSON: {"json"},
+}
+
+// ReleaseOutput is the machine-readable representation of a release.
+type ReleaseOutput struct {
+	ID          int64          `json:"id"`
+	HTMLURL     string         `json:"html_url"`
+	Tag         string         `json:"tag"`
+	Name        string         `json:"name"`
+	Draft       bool           `json:"draft"`
+	Prerelease  bool           `json:"prerelease"`
+	Latest      bool           `json:"latest"`
+	PublishedAt *time.Time     `json:"published_at,omitempty"`
+	Assets      []*AssetOutput `json:"assets"`
+}
+
+// AssetOutput is the machine-readable representation of a release asset.
+type AssetOutput struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int    `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Digest             string `json:"digest,omitempty"`
+	DownloadCount      int    `json:"download_count"`
+}
+
+// NewReleaseOutput fetches the assets of the release and whether it's the
+// latest release, and returns them as ReleaseOutput.
+func (g *GHR) NewReleaseOutput(ctx context.Context, release *github.RepositoryRelease) (*ReleaseOutput, error) {
+	assets, err := g.GitHub.ListAssets(ctx, release.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assets: %w", err)
+	}
+
+	var latestID int64
+	if !release.GetDraft() && !release.GetPrerelease() {
+		latestID, err = g.latestReleaseID(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newReleaseOutput(release, assets, latestID), nil
+}
+
+// ReleaseOutputs returns all releases of the repository as ReleaseOutput,
+// newest first. Digests of assets are not included.
+func (g *GHR) ReleaseOutputs(ctx context.Context) ([]*ReleaseOutput, error) {
+	releases, err := g.GitHub.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	latestID, err := g.latestReleaseID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	outs := make([]*ReleaseOutput, 0, len(releases))
+	for _, release := range releases {
+		assets := make([]*ReleaseAsset, 0, len(release.Assets))
+		for _, asset := range release.Assets {
+			assets = append(assets, &ReleaseAsset{ReleaseAsset: asset})
+		}
+		outs = append(outs, newReleaseOutput(release, assets, latestID))
+	}
+	return outs, nil
+}
+
+// latestReleaseID returns the ID of the latest release, or 0 when there is no
+// published release.
+func (g *GHR) latestReleaseID(ctx context.Context) (int64, error) {
+	latestRelease, err := g.GitHub.GetLatestRelease(ctx)
+	if err != nil {
+		if errors.Is(err, ErrReleaseNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get latest release: %w", err)
+	}
+	return latestRelease.GetID(), nil
+}
+
+func newReleaseOutput(release *github.RepositoryRelease, assets []*ReleaseAsset, latestID int64) *ReleaseOutput {
+	out := &ReleaseOutput{
+		ID:         release.GetID(),
+		HTMLURL:    release.GetHTMLURL(),
+		Tag:        release.GetTagName(),
+		Name:       release.GetName(),
+		Draft:      release.GetDraft(),
+		Prerelease: release.GetPrerelease(),
+		Latest:     latestID != 0 && release.GetID() == latestID,
+		Assets:     make([]*AssetOutput, 0, len(assets)),
+	}
+	if release.PublishedAt != nil {
+		out.PublishedAt = &release.PublishedAt.Time
+	}
+	for _, asset := range assets {
+		out.Assets = append(out.Assets, &AssetOutput{
+			ID:                 asset.GetID(),
+			Name:               asset.GetName(),
+			Size:               asset.GetSize(),
+			BrowserDownloadURL: asset.GetBrowserDownloadURL(),
+			Digest:             asset.GetDigest(),
+			DownloadCount:      asset.GetDownloadCount(),
+		})
+	}
+	return out
+}
+
+// Status returns the status of the release: draft, prerelease, latest or
+// published.
+func (r *ReleaseOutput) Status() string {
+	switch {
+	case r.Draft:
+		return "draft"
+	case r.Prerelease:
+		return "prerelease"
+	case r.Latest:
+		return "latest"
+	default:
+		return "published"
+	}
+}
+
+// WriteReleaseTable writes the releases to w as a table.
+func WriteReleaseTable(w io.Writer, releases []*ReleaseOutput) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tNAME\tSTATUS\tASSETS\tPUBLISHED")
+	for _, r := range releases {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", r.Tag, r.Name, r.Status(), len(r.Assets), formatDate(r.PublishedAt))
+	}
+	return tw.Flush()
+}
+
+// WriteRelease writes the release and its assets to w in a human readable
+// form.
+func WriteRelease(w io.Writer, r *ReleaseOutput) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Tag:\t%s\n", r.Tag)
+	fmt.Fprintf(tw, "Name:\t%s\n", r.Name)
+	fmt.Fprintf(tw, "Status:\t%s\n", r.Status())
+	fmt.Fprintf(tw, "Published:\t%s\n", formatDate(r.PublishedAt))
+	fmt.Fprintf(tw, "URL:\t%s\n", r.HTMLURL)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Assets) == 0 {
+		_, err := fmt.Fprintln(w, "\nNo assets")
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSIZE\tDOWNLOADS\tDIGEST")
+	for _, a := range r.Assets {
+		digest := a.Digest
+		if digest == "" {
+			digest = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", a.Name, formatBytes(int64(a.Size)), a.DownloadCount, digest)
+	}
+	return tw.Flush()
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.DateOnly)
+}
+
+// WriteJSON writes v to w as indented JSON.
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
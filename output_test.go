@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestGHR_NewReleaseOutput(t *testing.T) {
+	ghr := &GHR{
+		GitHub:    &dryRunGitHub{outStream: io.Discard},
+		outStream: io.Discard,
+	}
+	ctx := context.TODO()
+
+	release, err := ghr.GitHub.CreateRelease(ctx, &github.RepositoryRelease{
+		TagName: github.String("v1.0.0"),
+		Draft:   github.Bool(true),
+	})
+	if err != nil {
+		t.Fatal("CreateRelease failed:", err)
+	}
+
+	localAssets := []string{filepath.Join(TestDir, "darwin_386")}
+	if err := ghr.UploadAssets(ctx, *release.ID, localAssets, 1); err != nil {
+		t.Fatal("UploadAssets failed:", err)
+	}
+
+	release, err = ghr.GitHub.EditRelease(ctx, *release.ID, &github.RepositoryRelease{
+		Draft: github.Bool(false),
+	})
+	if err != nil {
+		t.Fatal("EditRelease failed:", err)
+	}
+
+	out, err := ghr.NewReleaseOutput(ctx, release)
+	if err != nil {
+		t.Fatal("NewReleaseOutput failed:", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, out); err != nil {
+		t.Fatal("WriteJSON failed:", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal("Unmarshal failed:", err)
+	}
+
+	want := map[string]interface{}{
+		"id":         float64(*release.ID),
+		"html_url":   "",
+		"tag":        "v1.0.0",
+		"name":       "",
+		"draft":      false,
+		"prerelease": false,
+		"latest":     true,
+		"assets": []interface{}{
+			map[string]interface{}{
+				"id":                   float64(0),
+				"name":                 "darwin_386",
+				"size":                 float64(11),
+				"browser_download_url": "",
+				"download_count":       float64(0),
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+}
+
+// releasesGitHub is a GitHub which has releases.
+type releasesGitHub struct {
+	GitHub
+	releases []*github.RepositoryRelease
+	latestID int64
+}
+
+func (g *releasesGitHub) ListReleases(ctx context.Context) ([]*github.RepositoryRelease, error) {
+	return g.releases, nil
+}
+
+func (g *releasesGitHub) GetLatestRelease(ctx context.Context) (*github.RepositoryRelease, error) {
+	for _, release := range g.releases {
+		if release.GetID() == g.latestID {
+			return release, nil
+		}
+	}
+	return nil, ErrReleaseNotFound
+}
+
+func TestWriteReleaseTable(t *testing.T) {
+	published := github.Timestamp{Time: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)}
+	ghr := &GHR{GitHub: &releasesGitHub{
+		releases: []*github.RepositoryRelease{
+			{ID: github.Int64(3), TagName: github.String("v1.1.0"), Name: github.String("v1.1.0"), Draft: github.Bool(true)},
+			{ID: github.Int64(2), TagName: github.String("v1.1.0-rc.1"), Name: github.String("RC"), Prerelease: github.Bool(true), PublishedAt: &published},
+			{ID: github.Int64(1), TagName: github.String("v1.0.0"), Name: github.String("First release"), PublishedAt: &published,
+				Assets: []*github.ReleaseAsset{{Name: github.String("darwin_386")}}},
+		},
+		latestID: 1,
+	}}
+
+	releases, err := ghr.ReleaseOutputs(context.TODO())
+	if err != nil {
+		t.Fatalf("ReleaseOutputs failed: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReleaseTable(&buf, releases); err != nil {
+		t.Fatalf("WriteReleaseTable failed: %s", err)
+	}
+	want := strings.Join([]string{
+		"TAG          NAME           STATUS      ASSETS  PUBLISHED",
+		"v1.1.0       v1.1.0         draft       0       -",
+		"v1.1.0-rc.1  RC             prerelease  0       2026-10-18",
+		"v1.0.0       First release  latest      1       2026-10-18",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Fatalf("WriteReleaseTable writes:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteRelease(t *testing.T) {
+	out := &ReleaseOutput{
+		Tag:     "v1.0.0",
+		Name:    "First release",
+		HTMLURL: "https://github.com/tcnksm/ghr/releases/tag/v1.0.0",
+		Assets: []*AssetOutput{
+			{Name: "darwin_386", Size: 2048, DownloadCount: 5, Digest: "sha256:abc"},
+			{Name: "SHA256SUMS", Size: 100},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRelease(&buf, out); err != nil {
+		t.Fatalf("WriteRelease failed: %s", err)
+	}
+	want := strings.Join([]string{
+		"Tag:        v1.0.0",
+		"Name:       First release",
+		"Status:     published",
+		"Published:  -",
+		"URL:        https://github.com/tcnksm/ghr/releases/tag/v1.0.0",
+		"",
+		"NAME        SIZE     DOWNLOADS  DIGEST",
+		"darwin_386  2.0 KiB  5          sha256:abc",
+		"SHA256SUMS  100 B    0          -",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Fatalf("WriteRelease writes:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "strings"
+
+// IsPrereleaseTag reports whether the tag is a semver with a prerelease
+// version, e.g., v2.0.0-rc.1, for '-prerelease=auto'. The version is the tag
+// without tagPrefix as in '-latest auto' (e.g., "cli/" for cli/v2.0.0-rc.1
+// in a monorepo).
+//
+// When identifiers are given, only versions whose first prerelease
+// identifier is one of them count, ignoring trailing digits (e.g., rc for
+// rc.1 and rc1). Tags which are not semver are never prereleases.
+func IsPrereleaseTag(tag, tagPrefix string, identifiers []string) bool {
+	v, err := tagVersion(tag, tagPrefix)
+	if err != nil {
+		Debugf("Prerelease: %s", err)
+		return false
+	}
+
+	pre := v.Prerelease()
+	if pre == "" {
+		return false
+	}
+	if len(identifiers) == 0 {
+		return true
+	}
+
+	id, _, _ := strings.Cut(pre, ".")
+	id = strings.TrimRight(id, "0123456789")
+	for _, identifier := range identifiers {
+		if strings.EqualFold(id, identifier) {
+			return true
+		}
+	}
+	Debugf("Prerelease: %s has prerelease version %s which is not one of %s", tag, pre, identifiers)
+	return false
+}
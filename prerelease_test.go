@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestIsPrereleaseTag(t *testing.T) {
+	cases := []struct {
+		tag, prefix string
+		identifiers []string
+		want        bool
+	}{
+		{"v2.0.0-rc.1", "", nil, true},
+		{"2.0.0-beta", "", nil, true},
+		{"v2.0.0", "", nil, false},
+		{"nightly", "", nil, false},
+
+		// The prefix is removed as in -latest auto.
+		{"cli/v1.2.0-alpha.3", "cli/", nil, true},
+		{"cli-v2.0.0-rc.1", "cli-", nil, true},
+		{"cli/v1.2.0", "cli/", nil, false},
+		{"cli/v1.2.0-alpha.3", "", nil, false},
+		{"v1.2.0-alpha.3", "cli/", nil, false},
+
+		{"v2.0.0-rc.1", "", []string{"alpha", "beta", "rc"}, true},
+		{"v2.0.0-RC1", "", []string{"alpha", "beta", "rc"}, true},
+		{"v2.0.0-nightly.20261018", "", []string{"nightly"}, true},
+		{"v2.0.0-hotfix.1", "", []string{"alpha", "beta", "rc"}, false},
+		{"v2.0.0", "", []string{"rc"}, false},
+	}
+
+	for i, tc := range cases {
+		if got := IsPrereleaseTag(tc.tag, tc.prefix, tc.identifiers); got != tc.want {
+			t.Fatalf("#%d IsPrereleaseTag(%q, %q, %q) = %t; want %t", i, tc.tag, tc.prefix, tc.identifiers, got, tc.want)
+		}
+	}
+}
+
+func TestPrereleaseFlag(t *testing.T) {
+	cases := []struct {
+		args []string
+		want prereleaseFlag
+	}{
+		{nil, "false"},
+		{[]string{"-prerelease"}, "true"},
+		{[]string{"-prerelease=false"}, "false"},
+		{[]string{"-prerelease=AUTO"}, prereleaseAuto},
+	}
+
+	for i, tc := range cases {
+		p := prereleaseFlag("false")
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.Var(&p, "prerelease", "")
+		if err := flags.Parse(tc.args); err != nil {
+			t.Fatalf("#%d Parse failed: %s", i, err)
+		}
+		if p != tc.want {
+			t.Fatalf("#%d -prerelease = %q; want %q", i, p, tc.want)
+		}
+	}
+
+	p := prereleaseFlag("false")
+	if err := p.Set("sometimes"); err == nil {
+		t.Fatal("expect Set to fail")
+	}
+}
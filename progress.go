@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	// progressTTYInterval is the interval to redraw the progress on a
+	// terminal.
+	progressTTYInterval = 200 * time.Millisecond
+
+	// progressLogInterval is the interval to print the progress as log lines
+	// when the output is not a terminal.
+	progressLogInterval = 10 * time.Second
+)
+
+// progressReader is an io.Reader which reports the number of bytes read so far.
+type progressReader struct {
+	r      io.Reader
+	n      int64
+	report func(n int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.report != nil {
+		r.report(r.n)
+	}
+	return n, err
+}
+
+// uploadProgress tracks and displays the progress of uploading assets. On a
+// terminal, it shows a live display with a line for each asset and a line for
+// the total. Otherwise it prints the progress as log lines periodically.
+type uploadProgress struct {
+	w   io.Writer
+	tty bool
+
+	mu     sync.Mutex
+	start  time.Time
+	assets []*assetProgress
+	lines  int
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// assetProgress is the progress of uploading an asset. It implements
+// UploadProgress.
+type assetProgress struct {
+	p *uploadProgress
+
+	name       string
+	size, sent int64
+	retries    int
+	start      time.Time
+	elapsed    time.Duration
+	finished   bool
+	err        error
+}
+
+// Sent sets the number of bytes sent in the current attempt.
+func (a *assetProgress) Sent(n int64) {
+	a.p.mu.Lock()
+	defer a.p.mu.Unlock()
+	a.sent = n
+}
+
+// Retry counts a retry. Bytes are sent from the beginning again.
+func (a *assetProgress) Retry(err error) {
+	a.p.mu.Lock()
+	defer a.p.mu.Unlock()
+	a.retries++
+	a.sent = 0
+	Debugf("Retry uploading %s: %s", a.name, err)
+}
+
+// newUploadProgress starts tracking the progress and displaying it to w.
+func newUploadProgress(w io.Writer) *uploadProgress {
+	p := &uploadProgress{
+		w:     w,
+		tty:   isTerminal(w),
+		start: time.Now(),
+		done:  make(chan struct{}),
+	}
+
+	interval := progressLogInterval
+	if p.tty {
+		interval = progressTTYInterval
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.render()
+				p.mu.Unlock()
+			}
+		}
+	}()
+
+	return p
+}
+
+// Printf prints a message without breaking the live display.
+func (p *uploadProgress) Printf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clear()
+	fmt.Fprintf(p.w, format, args...)
+	if p.tty {
+		p.draw()
+	}
+}
+
+// Add adds an asset to track.
+func (p *uploadProgress) Add(name string, size int64) *assetProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	a := &assetProgress{p: p, name: name, size: size, start: time.Now()}
+	p.assets = append(p.assets, a)
+	return a
+}
+
+// Finish marks the asset as finished. err is the error of the upload, if any.
+func (p *uploadProgress) Finish(a *assetProgress, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	a.finished = true
+	a.elapsed = time.Since(a.start)
+	a.err = err
+}
+
+// Results returns the results of uploading the assets.
+func (p *uploadProgress) Results() []*UploadResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	results := make([]*UploadResult, 0, len(p.assets))
+	for _, a := range p.assets {
+		results = append(results, &UploadResult{
+			Name:    a.name,
+			Size:    a.size,
+			Retries: a.retries,
+			Elapsed: a.elapsed,
+			Err:     a.err,
+		})
+	}
+	return results
+}
+
+// Stop stops displaying the progress and prints the final state.
+func (p *uploadProgress) Stop() {
+	close(p.done)
+	p.wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.tty {
+		p.clear()
+		p.draw()
+		p.lines = 0
+	}
+	if len(p.assets) != 0 {
+		finished := 0
+		for _, a := range p.assets {
+			if a.finished && a.err == nil {
+				finished++
+			}
+		}
+		fmt.Fprintf(p.w, "==> Uploaded %d/%d assets: %s\n",
+			finished, len(p.assets), p.total().status(time.Since(p.start)))
+	}
+}
+
+// render redraws the live display on a terminal, or prints the progress of
+// unfinished assets as log lines.
+func (p *uploadProgress) render() {
+	if p.tty {
+		p.clear()
+		p.draw()
+		return
+	}
+
+	active := false
+	for _, a := range p.assets {
+		if a.finished {
+			continue
+		}
+		active = true
+		fmt.Fprintf(p.w, "    %s: %s\n", a.name, a.status(time.Since(a.start)))
+	}
+	if active {
+		fmt.Fprintf(p.w, "    total: %s\n", p.total().status(time.Since(p.start)))
+	}
+}
+
+// clear erases the lines drawn by draw.
+func (p *uploadProgress) clear() {
+	if p.lines == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "\x1b[%dA", p.lines)
+	for i := 0; i < p.lines; i++ {
+		fmt.Fprint(p.w, "\x1b[2K\n")
+	}
+	fmt.Fprintf(p.w, "\x1b[%dA", p.lines)
+	p.lines = 0
+}
+
+func (p *uploadProgress) draw() {
+	if len(p.assets) == 0 {
+		return
+	}
+
+	width := 0
+	for _, a := range p.assets {
+		width = max(width, len(a.name))
+	}
+
+	var b strings.Builder
+	for _, a := range p.assets {
+		elapsed := time.Since(a.start)
+		if a.finished {
+			elapsed = a.elapsed
+		}
+		fmt.Fprintf(&b, "    %-*s  %s\n", width, a.name, a.status(elapsed))
+	}
+	fmt.Fprintf(&b, "    %-*s  %s\n", width, "total", p.total().status(time.Since(p.start)))
+
+	fmt.Fprint(p.w, b.String())
+	p.lines = len(p.assets) + 1
+}
+
+func (p *uploadProgress) total() *assetProgress {
+	total := &assetProgress{finished: true}
+	for _, a := range p.assets {
+		total.size += a.size
+		total.sent += a.sent
+		total.finished = total.finished && a.finished
+	}
+	return total
+}
+
+// status returns the bytes sent, percentage, rate and ETA.
+func (a *assetProgress) status(elapsed time.Duration) string {
+	percent := 100.0
+	if a.size > 0 {
+		percent = float64(a.sent) * 100 / float64(a.size)
+	}
+
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(a.sent) / elapsed.Seconds()
+	}
+
+	s := fmt.Sprintf("%5.1f%% %s / %s, %s/s", percent, formatBytes(a.sent), formatBytes(a.size), formatBytes(int64(rate)))
+	if a.err != nil {
+		return s + ", failed"
+	}
+	if a.finished {
+		return s + fmt.Sprintf(", done in %s", elapsed.Round(time.Second))
+	}
+	if rate > 0 {
+		eta := time.Duration(float64(a.size-a.sent) / rate * float64(time.Second))
+		s += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+	}
+	return s
+}
+
+// formatBytes formats n bytes in a human readable form, e.g., 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// isTerminal reports whether w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatBytes(t *testing.T) {
+	cases := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tc := range cases {
+		if got := formatBytes(tc.in); got != tc.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestAssetProgress_status(t *testing.T) {
+	a := &assetProgress{size: 4 << 20, sent: 1 << 20}
+	if got, want := a.status(time.Second), " 25.0% 1.0 MiB / 4.0 MiB, 1.0 MiB/s, ETA 3s"; got != want {
+		t.Fatalf("status = %q, want %q", got, want)
+	}
+
+	a = &assetProgress{size: 4 << 20, sent: 4 << 20, finished: true}
+	if got, want := a.status(2*time.Second), "100.0% 4.0 MiB / 4.0 MiB, 2.0 MiB/s, done in 2s"; got != want {
+		t.Fatalf("status = %q, want %q", got, want)
+	}
+}
+
+func TestUploadProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := newUploadProgress(&buf)
+
+	p.Printf("--> Uploading: %s\n", "ghr")
+	a := p.Add("ghr", 10)
+
+	r := &progressReader{r: strings.NewReader("0123456789"), report: a.Sent}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal("Copy failed:", err)
+	}
+	if a.sent != 10 {
+		t.Fatalf("sent = %d, want 10", a.sent)
+	}
+
+	p.Finish(a, nil)
+	p.Stop()
+
+	out := buf.String()
+	for _, want := range []string{"--> Uploading: ghr\n", "==> Uploaded 1/1 assets: 100.0% 10 B / 10 B"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q, want %q", out, want)
+		}
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("output %q contains escape sequences for non terminal", out)
+	}
+}
+
+func TestUploadProgress_Results(t *testing.T) {
+	p := newUploadProgress(io.Discard)
+
+	a := p.Add("darwin_386", 11)
+	a.Sent(11)
+	p.Finish(a, nil)
+
+	b := p.Add("linux_386", 10)
+	b.Sent(5)
+	b.Retry(errors.New("502 Bad Gateway"))
+	b.Retry(errors.New("502 Bad Gateway"))
+	p.Finish(b, errors.New("502 Bad Gateway"))
+	p.Stop()
+
+	results := p.Results()
+	if got, want := len(results), 2; got != want {
+		t.Fatalf("Results number = %d, want %d", got, want)
+	}
+	if results[0].Err != nil || results[0].Retries != 0 {
+		t.Errorf("result of %s = %+v, want success without retry", results[0].Name, results[0])
+	}
+	if results[1].Err == nil || results[1].Retries != 2 {
+		t.Errorf("result of %s = %+v, want failure with 2 retries", results[1].Name, results[1])
+	}
+
+	var buf bytes.Buffer
+	printUploadResults(&buf, results)
+	for _, want := range []string{"darwin_386", "ok", "linux_386", "failed: 502 Bad Gateway"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("printUploadResults outputs %q, want %q", buf.String(), want)
+		}
+	}
+
+	err := &UploadError{Results: results}
+	if got, want := err.Error(), "1 of 2 assets failed to upload: linux_386"; got != want {
+		t.Errorf("UploadError = %q, want %q", got, want)
+	}
+}
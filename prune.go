@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+const (
+	releaseKindRelease    = "release"
+	releaseKindPrerelease = "prerelease"
+	releaseKindDraft      = "draft"
+)
+
+// PrunePolicy decides which releases are deleted by Prune. A release is
+// deleted only when it matches the kinds and the tag filters and it's beyond
+// every limit which is set. The latest release is never deleted.
+type PrunePolicy struct {
+	// Keep is the number of the newest releases to keep for each kind.
+	// Negative means no limit by number.
+	Keep int
+
+	// OlderThan keeps releases which are newer than it. Zero means no
+	// limit by age.
+	OlderThan time.Duration
+
+	// Kinds are the kinds of releases to prune: release, prerelease and
+	// draft. Releases of other kinds are left as they are.
+	Kinds []string
+
+	// TagPattern and TagRegexp restrict releases to prune to the ones whose
+	// tag matches them, if set.
+	TagPattern string
+	TagRegexp  *regexp.Regexp
+
+	// KeepTag keeps the git tags of the deleted releases.
+	KeepTag bool
+
+	// MaxDelete is the maximum number of releases to delete at once. When
+	// more releases match, Prune deletes the oldest ones up to it and leaves
+	// the others to the next run. Zero means no cap.
+	MaxDelete int
+}
+
+// Validate checks the policy configured by the user.
+func (p *PrunePolicy) Validate() error {
+	if p.Keep < 0 && p.OlderThan <= 0 {
+		return errors.New("either keep or older-than must be set")
+	}
+	if len(p.Kinds) == 0 {
+		return errors.New("no kinds of releases to prune")
+	}
+	for _, kind := range p.Kinds {
+		switch kind {
+		case releaseKindRelease, releaseKindPrerelease, releaseKindDraft:
+		default:
+			return fmt.Errorf("invalid kind of release: %q", kind)
+		}
+	}
+	if p.TagPattern != "" {
+		if _, err := path.Match(p.TagPattern, ""); err != nil {
+			return fmt.Errorf("invalid tag pattern %q: %w", p.TagPattern, err)
+		}
+	}
+	if p.MaxDelete < 0 {
+		return fmt.Errorf("max delete must not be negative: %d", p.MaxDelete)
+	}
+	return nil
+}
+
+// Select returns the releases to delete, oldest first. latestID is the ID of
+// the latest release, which is kept.
+func (p *PrunePolicy) Select(releases []*github.RepositoryRelease, latestID int64, now time.Time) []*github.RepositoryRelease {
+	releases = append([]*github.RepositoryRelease(nil), releases...)
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releaseTime(releases[i]).After(releaseTime(releases[j]))
+	})
+
+	kinds := make(map[string]bool, len(p.Kinds))
+	for _, kind := range p.Kinds {
+		kinds[kind] = true
+	}
+
+	var selected []*github.RepositoryRelease
+	counts := make(map[string]int)
+	for _, release := range releases {
+		kind := releaseKind(release)
+		if !kinds[kind] || !p.matchTag(release.GetTagName()) {
+			continue
+		}
+
+		// Releases of each kind are counted separately, e.g., nightly
+		// prereleases do not push stable releases out.
+		counts[kind]++
+		if p.Keep >= 0 && counts[kind] <= p.Keep {
+			continue
+		}
+		if p.OlderThan > 0 && now.Sub(releaseTime(release)) < p.OlderThan {
+			continue
+		}
+		if release.GetID() == latestID {
+			continue
+		}
+		selected = append(selected, release)
+	}
+
+	// Delete the oldest first.
+	for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+		selected[i], selected[j] = selected[j], selected[i]
+	}
+	return selected
+}
+
+func (p *PrunePolicy) matchTag(tag string) bool {
+	if p.TagPattern != "" {
+		if ok, _ := path.Match(p.TagPattern, tag); !ok {
+			return false
+		}
+	}
+	if p.TagRegexp != nil && !p.TagRegexp.MatchString(tag) {
+		return false
+	}
+	return true
+}
+
+// Prune deletes the releases selected by the policy and returns the number of
+// deleted releases.
+func (g *GHR) Prune(ctx context.Context, policy *PrunePolicy) (int, error) {
+	releases, err := g.GitHub.ListReleases(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	latestID, err := g.latestReleaseID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	prune := policy.Select(releases, latestID, time.Now())
+	if len(prune) == 0 {
+		fmt.Fprintln(g.outStream, "==> No releases to prune")
+		return 0, nil
+	}
+
+	// prune is sorted oldest first, so the newer ones are left to the next
+	// run.
+	remaining := 0
+	if policy.MaxDelete > 0 && len(prune) > policy.MaxDelete {
+		remaining = len(prune) - policy.MaxDelete
+		prune = prune[:policy.MaxDelete]
+	}
+
+	fmt.Fprintf(g.outStream, "==> Prune %d of %d releases\n", len(prune), len(releases))
+	if remaining > 0 {
+		fmt.Fprintf(g.outStream, "WARNING: %d more releases match the policy beyond max delete %d\n",
+			remaining, policy.MaxDelete)
+	}
+
+	for i, release := range prune {
+		fmt.Fprintf(g.outStream, "--> Deleting: %15s (%s, %s)\n",
+			release.GetTagName(), releaseKind(release), releaseTime(release).Format(time.DateOnly))
+
+		// A draft release has no tag yet.
+		tag := release.GetTagName()
+		if policy.KeepTag || release.GetDraft() {
+			tag = ""
+		}
+		if err := g.deleteRelease(ctx, release.GetID(), tag); err != nil {
+			return i, fmt.Errorf("failed to delete release %s: %w", release.GetTagName(), err)
+		}
+	}
+
+	return len(prune), nil
+}
+
+func releaseKind(release *github.RepositoryRelease) string {
+	switch {
+	case release.GetDraft():
+		return releaseKindDraft
+	case release.GetPrerelease():
+		return releaseKindPrerelease
+	default:
+		return releaseKindRelease
+	}
+}
+
+// releaseTime returns when the release is published, or created for drafts.
+func releaseTime(release *github.RepositoryRelease) time.Time {
+	if release.PublishedAt != nil {
+		return release.PublishedAt.Time
+	}
+	return release.GetCreatedAt().Time
+}
+
+// ParseAge parses a duration which also accepts days, e.g., "30d".
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age: %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age: %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestPrunePolicy_Select(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	release := func(id int64, tag, kind string, days int) *github.RepositoryRelease {
+		r := &github.RepositoryRelease{
+			ID:         github.Int64(id),
+			TagName:    github.String(tag),
+			Draft:      github.Bool(kind == releaseKindDraft),
+			Prerelease: github.Bool(kind == releaseKindPrerelease),
+			CreatedAt:  &github.Timestamp{Time: now.AddDate(0, 0, -days)},
+		}
+		if kind != releaseKindDraft {
+			r.PublishedAt = r.CreatedAt
+		}
+		return r
+	}
+
+	releases := []*github.RepositoryRelease{
+		release(8, "nightly-8", releaseKindPrerelease, 1),
+		release(7, "v1.1.0", releaseKindDraft, 2),
+		release(6, "nightly-6", releaseKindPrerelease, 3),
+		release(5, "nightly-5", releaseKindPrerelease, 40),
+		release(4, "v1.0.0", releaseKindRelease, 50),
+		release(3, "nightly-3", releaseKindPrerelease, 60),
+		release(2, "v0.9.0", releaseKindRelease, 70),
+		release(1, "v0.8.0", releaseKindRelease, 80),
+	}
+
+	tags := func(releases []*github.RepositoryRelease) []string {
+		var tags []string
+		for _, r := range releases {
+			tags = append(tags, r.GetTagName())
+		}
+		return tags
+	}
+
+	cases := []struct {
+		policy PrunePolicy
+		want   []string
+	}{
+		// 0: Kinds are counted separately and the latest release is kept.
+		{
+			PrunePolicy{Keep: 1, Kinds: []string{releaseKindRelease, releaseKindPrerelease}},
+			[]string{"v0.8.0", "v0.9.0", "nightly-3", "nightly-5", "nightly-6"},
+		},
+
+		// 1: Both keep and age limits apply.
+		{
+			PrunePolicy{Keep: 1, OlderThan: 30 * 24 * time.Hour, Kinds: []string{releaseKindPrerelease}},
+			[]string{"nightly-3", "nightly-5"},
+		},
+
+		// 2: Tag filters
+		{
+			PrunePolicy{Keep: -1, OlderThan: 24 * time.Hour, Kinds: []string{releaseKindRelease, releaseKindPrerelease},
+				TagPattern: "nightly-*", TagRegexp: regexp.MustCompile(`[35]$`)},
+			[]string{"nightly-3", "nightly-5"},
+		},
+
+		// 3: Drafts
+		{
+			PrunePolicy{Keep: 0, Kinds: []string{releaseKindDraft}},
+			[]string{"v1.1.0"},
+		},
+	}
+
+	for i, tc := range cases {
+		if err := tc.policy.Validate(); err != nil {
+			t.Fatalf("#%d Validate failed: %s", i, err)
+		}
+		got := tags(tc.policy.Select(releases, 4, now))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("#%d Select = %q; want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestPrunePolicy_Validate(t *testing.T) {
+	cases := []PrunePolicy{
+		{Keep: -1, Kinds: []string{releaseKindRelease}},
+		{Keep: 1, Kinds: []string{"nightly"}},
+		{Keep: 1, Kinds: []string{releaseKindRelease}, TagPattern: "["},
+		{Keep: 1},
+	}
+	for i, policy := range cases {
+		if err := policy.Validate(); err == nil {
+			t.Fatalf("#%d expect Validate to fail", i)
+		}
+	}
+}
+
+func TestGHR_Prune(t *testing.T) {
+	now := time.Now()
+	var releases []*github.RepositoryRelease
+	for i := 1; i <= 3; i++ {
+		published := &github.Timestamp{Time: now.AddDate(0, 0, -i)}
+		releases = append(releases, &github.RepositoryRelease{
+			ID:          github.Int64(int64(i)),
+			TagName:     github.String(fmt.Sprintf("v0.0.%d", i)),
+			CreatedAt:   published,
+			PublishedAt: published,
+		})
+	}
+
+	var buf bytes.Buffer
+	ghr := &GHR{
+		GitHub: &dryRunGitHub{
+			GitHub:    &releasesGitHub{releases: releases, latestID: 1},
+			outStream: &buf,
+		},
+		outStream: &buf,
+	}
+
+	// Only the oldest one is deleted by max delete.
+	policy := &PrunePolicy{Keep: 0, Kinds: []string{releaseKindRelease}, KeepTag: true, MaxDelete: 1}
+	n, err := ghr.Prune(context.TODO(), policy)
+	if err != nil {
+		t.Fatalf("Prune failed: %s", err)
+	}
+	if n != 1 {
+		t.Fatalf("Prune deletes %d releases; want 1", n)
+	}
+
+	want := strings.Join([]string{
+		"==> Prune 1 of 3 releases",
+		"WARNING: 1 more releases match the policy beyond max delete 1",
+		"--> Deleting:          v0.0.3 (release, " + now.AddDate(0, 0, -3).Format(time.DateOnly) + ")",
+		"[dry-run] delete release 3",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Fatalf("Prune prints:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	policy.MaxDelete = 0
+	n, err = ghr.Prune(context.TODO(), policy)
+	if err != nil {
+		t.Fatalf("Prune failed: %s", err)
+	}
+	if n != 2 {
+		t.Fatalf("Prune deletes %d releases; want 2", n)
+	}
+
+	want = strings.Join([]string{
+		"==> Prune 2 of 3 releases",
+		"--> Deleting:          v0.0.3 (release, " + now.AddDate(0, 0, -3).Format(time.DateOnly) + ")",
+		"[dry-run] delete release 3",
+		"--> Deleting:          v0.0.2 (release, " + now.AddDate(0, 0, -2).Format(time.DateOnly) + ")",
+		"[dry-run] delete release 2",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Fatalf("Prune prints:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/tcnksm/go-gitconfig"
+)
+
+const (
+	defaultRemote = "origin"
+	gitHubHost    = "github.com"
+)
+
+// RemoteURL is the repository which a git remote URL points to.
+type RemoteURL struct {
+	Host  string
+	Owner string
+	Repo  string
+
+	// Scheme is the scheme of the URL, e.g., https or ssh, or "" for an
+	// scp-like address.
+	Scheme string
+}
+
+// ParseRemoteURL parses a git remote URL, either a URL like
+// https://github.com/tcnksm/ghr.git and ssh://git@host:2222/tcnksm/ghr or
+// an scp-like address like git@github.com:tcnksm/ghr.git. The last two
+// elements of the path are the owner and the repository name, so a path
+// prefix of the server is ignored.
+func ParseRemoteURL(s string) (*RemoteURL, error) {
+	var host, path, scheme string
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote URL %q: %w", s, err)
+		}
+		// The port of HTTP(S) is also the one of the API, but the one of
+		// SSH is not.
+		host = u.Host
+		if u.Scheme != "http" && u.Scheme != "https" {
+			host = u.Hostname()
+		}
+		path, scheme = u.Path, u.Scheme
+	} else {
+		// scp-like address: [user@]host:path
+		i := strings.Index(s, ":")
+		if i < 0 || strings.Contains(s[:i], "/") {
+			return nil, fmt.Errorf("invalid remote URL %q: not a URL of a remote repository", s)
+		}
+		host, path = s[:i], s[i+1:]
+		if j := strings.LastIndex(host, "@"); j >= 0 {
+			host = host[j+1:]
+		}
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	elems := strings.Split(path, "/")
+	if host == "" || len(elems) < 2 || elems[len(elems)-2] == "" || elems[len(elems)-1] == "" {
+		return nil, fmt.Errorf("invalid remote URL %q: owner and repository not found", s)
+	}
+
+	return &RemoteURL{
+		Host:   strings.ToLower(host),
+		Owner:  elems[len(elems)-2],
+		Repo:   elems[len(elems)-1],
+		Scheme: scheme,
+	}, nil
+}
+
+// APIURL returns the base URL of the GitHub API of the host, or "" for
+// github.com. GitHub Enterprise Server serves it at /api/v3/ and GitHub
+// Enterprise Cloud with data residency (*.ghe.com) at the api subdomain.
+//
+// Other hosts may not be GitHub at all (e.g., a mirror on gitlab.com) or
+// may be an alias in ~/.ssh/config (e.g., git@github-work:org/repo). The
+// token must not be sent to them, so a host is taken as GitHub Enterprise
+// Server only when the gh CLI knows it. Otherwise "" is returned.
+func (r *RemoteURL) APIURL() string {
+	switch {
+	case r.Host == gitHubHost || strings.HasSuffix(r.Host, "."+gitHubHost):
+		return ""
+	case strings.HasSuffix(r.Host, ".ghe.com"):
+		return "https://api." + r.Host + "/"
+	case GHHostKnown(r.Host):
+		// The API of a server known by an HTTP remote is served over HTTP.
+		scheme := "https"
+		if r.Scheme == "http" {
+			scheme = "http"
+		}
+		return scheme + "://" + r.Host + "/api/v3/"
+	default:
+		Debugf("Remote host %s is not known as GitHub Enterprise: use the default API URL", r.Host)
+		return ""
+	}
+}
+
+// GitRemoteURL returns the URL of the git remote of the current repository.
+func GitRemoteURL(name string) (string, error) {
+	return gitconfig.Local("remote." + name + ".url")
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/tcnksm/go-gitconfig"
+)
+
+// repositoryHelpText is the help of the flags of repositoryFlags, which is
+// shared by the help of ghr and its subcommands.
+const repositoryHelpText = `-username, -owner, -u
+	Github repository owner name. By default, ghr extracts it from the URL
+	of the git remote or global gitconfig value.
+
+-repository, -r
+	GitHub repository name. By default, ghr extracts repository name from
+	the URL of the git remote in current directory's .git/config.
+
+-token, -t
+	GitHub API Token. By default, ghr reads it from 'GITHUB_TOKEN' env var.
+	Otherwise, ghr tries '-token-command', hosts.yml of the gh CLI for the
+	host, the password of the API host (e.g., api.github.com) in ~/.netrc
+	and 'github.token' in gitconfig in this order.
+
+-token-command=COMMAND
+	Run COMMAND with the shell and use its output as the GitHub API token,
+	e.g., -token-command 'op read op://ci/github/token'. It can not be set
+	in config files.
+
+-app-id=ID
+	App ID or Client ID of the GitHub App to authenticate as instead of
+	the API token. Requires '-app-private-key'. ghr creates installation
+	tokens of the App which can access only the repository and refreshes
+	them before they expire.
+
+-app-private-key=PATH
+	Private key of the GitHub App in PEM, either the path to the file or
+	the key itself, e.g., in GHR_APP_PRIVATE_KEY env var.
+
+-installation-id=ID
+	Installation of the GitHub App. By default, ghr looks up the one for
+	the repository.
+
+-api-url=URL
+	Base URL of the GitHub API, e.g., for GitHub Enterprise. By default,
+	ghr reads it from 'GITHUB_API' env var, or infers it from the git
+	remote when its host is in hosts.yml of the gh CLI
+	(https://HOST/api/v3/) or on *.ghe.com. The token is never sent to
+	other hosts of the remote.
+
+-remote=origin
+	Name of the git remote from which ghr extracts the owner and the
+	repository name. HTTPS, SSH and scp-like (git@HOST:OWNER/REPO) URLs
+	are supported.
+
+-profile=NAME
+	Use the profile NAME of the config files. See 'Config files' in
+	'ghr -h'.
+
+-retry-max-attempts=3
+	Maximum number of attempts of each GitHub API request. Requests are
+	retried with exponential backoff on rate limiting, and also on server
+	and network errors when it's safe to send them again. The wait GitHub
+	asks for by Retry-After or X-RateLimit-Reset is honored.
+
+-retry-max-wait=1m
+	Maximum wait before a retry. ghr gives up instead of waiting longer
+	when GitHub asks to (e.g., the rate limit resets in an hour).
+`
+
+// repositoryFlags are the flags to access a GitHub repository. They are
+// shared by ghr and its subcommands.
+type repositoryFlags struct {
+	owner  string
+	repo   string
+	token  string
+	apiURL string
+	remote string
+
+	tokenCommand string
+
+	appID          string
+	appPrivateKey  string
+	appKey         *rsa.PrivateKey
+	installationID int64
+
+	profile string
+
+	retryMaxAttempts int
+	retryMaxWait     time.Duration
+}
+
+// register defines the flags in flags.
+func (f *repositoryFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.owner, "username", "", "")
+	flags.StringVar(&f.owner, "owner", "", "")
+	flags.StringVar(&f.owner, "u", "", "")
+
+	flags.StringVar(&f.repo, "repository", "", "")
+	flags.StringVar(&f.repo, "r", "", "")
+
+	flags.StringVar(&f.token, "token", os.Getenv(EnvGitHubToken), "")
+	flags.StringVar(&f.token, "t", os.Getenv(EnvGitHubToken), "")
+
+	flags.StringVar(&f.tokenCommand, "token-command", "", "")
+
+	flags.StringVar(&f.appID, "app-id", "", "")
+	flags.StringVar(&f.appPrivateKey, "app-private-key", "", "")
+	flags.Int64Var(&f.installationID, "installation-id", 0, "")
+
+	flags.StringVar(&f.apiURL, "api-url", "", "")
+	flags.StringVar(&f.remote, "remote", defaultRemote, "")
+	flags.StringVar(&f.profile, "profile", "", "")
+
+	flags.IntVar(&f.retryMaxAttempts, "retry-max-attempts", defaultRetryMaxAttempts, "")
+	flags.DurationVar(&f.retryMaxWait, "retry-max-wait", defaultRetryMaxWait, "")
+}
+
+// newFlagSet returns the flag set of the subcommand name with the flags of
+// the repository. Its usage prints helpText.
+func (f *repositoryFlags) newFlagSet(name, helpText string, errStream io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(Name+" "+name, flag.ContinueOnError)
+	flags.SetOutput(errStream)
+	flags.Usage = func() {
+		fmt.Fprint(errStream, EnvHelp(helpText, flags))
+	}
+	f.register(flags)
+	return flags
+}
+
+// parse parses the arguments of the subcommand, enables debug output with
+// -debug and applies env vars and config files to the flags. It prints why
+// and returns the exit code when it fails, or ExitCodeOK.
+func (f *repositoryFlags) parse(flags *flag.FlagSet, args []string, errStream io.Writer) int {
+	var debug bool
+	flags.BoolVar(&debug, "debug", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return ExitCodeParseFlagsError
+	}
+
+	if debug {
+		os.Setenv(EnvDebug, "1")
+		Debugf("Run as DEBUG mode")
+	}
+
+	return f.applyConfig(flags, errStream)
+}
+
+// applyConfig sets the flags which are not given on the command line from
+// env vars and config files. It prints why and returns the exit code when it
+// fails, or ExitCodeOK.
+func (f *repositoryFlags) applyConfig(flags *flag.FlagSet, errStream io.Writer) int {
+	// Env vars are applied first since GHR_PROFILE selects the profile.
+	if err := ApplyEnv(flags); err != nil {
+		PrintRedf(errStream, "Invalid env var: %s\n", err)
+		return ExitCodeBadArgs
+	}
+
+	configs, err := LoadConfigs()
+	if err == nil {
+		err = ApplyConfig(flags, f.profile, configs...)
+	}
+	if err != nil {
+		PrintRedf(errStream, "Failed to load config: %s\n", err)
+		return ExitCodeBadArgs
+	}
+	return ExitCodeOK
+}
+
+// resolve fills the owner, the repository name, the API URL and the token
+// which are not given by flags from the git remote and .gitconfig and
+// validates the flags. It prints why and returns the exit code when it
+// fails, or ExitCodeOK.
+func (f *repositoryFlags) resolve(errStream io.Writer) int {
+	// Read the owner and the repository name from the URL of the git
+	// remote, which must exist when it's given explicitly.
+	var remote *RemoteURL
+	if len(f.owner) == 0 || len(f.repo) == 0 {
+		remoteURL, err := GitRemoteURL(f.remote)
+		if err == nil {
+			remote, err = ParseRemoteURL(remoteURL)
+		}
+		if err != nil && f.remote != defaultRemote {
+			PrintRedf(errStream, "Failed to set up ghr: remote %s: %s\n", f.remote, err)
+			return ExitCodeRepoNotFound
+		}
+		if err != nil {
+			Debugf("Remote %s: %s", f.remote, err)
+		}
+	}
+	if remote != nil {
+		Debugf("Remote %s: %+v", f.remote, *remote)
+		if len(f.owner) == 0 {
+			f.owner = remote.Owner
+		}
+		if len(f.repo) == 0 {
+			f.repo = remote.Repo
+		}
+
+		// The repository is on GitHub Enterprise when the remote is known
+		// to be on it.
+		if len(f.apiURL) == 0 {
+			f.apiURL = remote.APIURL()
+		}
+	}
+
+	// If the owner is not provided via command line flag or the remote,
+	// read it from .gitconfig (github user or git user).
+	if len(f.owner) == 0 {
+		var err error
+		f.owner, err = gitconfig.GithubUser()
+		if err != nil {
+			f.owner, err = gitconfig.Username()
+		}
+
+		if err != nil {
+			PrintRedf(errStream,
+				"Failed to set up ghr: repository owner name not found\n")
+			fmt.Fprintf(errStream,
+				"Please set it via `-u` option.\n\n"+
+					"You can set default owner name in `github.username` or `user.name`\n"+
+					"in `~/.gitconfig` file\n")
+			return ExitCodeOwnerNotFound
+		}
+	}
+	Debugf("Owner: %s", f.owner)
+
+	if len(f.repo) == 0 {
+		PrintRedf(errStream,
+			"Failed to set up ghr: repository name not found\n")
+		fmt.Fprintf(errStream,
+			"ghr reads it from the URL of the git remote (`-remote`, origin by\n"+
+				"default). Change directory to repository root directory or\n"+
+				"setup git repository. Or set it via `-r` option.\n")
+		return ExitCodeRepoNotFound
+	}
+	Debugf("Repository: %s", f.repo)
+
+	// A GitHub App authenticates with its installation tokens instead of
+	// the API token.
+	if len(f.appID) != 0 || len(f.appPrivateKey) != 0 {
+		if len(f.appID) == 0 || len(f.appPrivateKey) == 0 {
+			PrintRedf(errStream,
+				"Failed to set up ghr: both `-app-id` and `-app-private-key` must be set\n")
+			return ExitCodeBadArgs
+		}
+
+		var err error
+		f.appKey, err = ReadAppPrivateKey(f.appPrivateKey)
+		if err != nil {
+			PrintRedf(errStream, "Failed to set up ghr: %s\n", err)
+			return ExitCodeBadArgs
+		}
+		Debugf("GitHub App: %s", f.appID)
+	} else {
+		// If GitHub API token is not provided via command line flag
+		// or env var then find it from the token sources.
+		source := "-token or " + EnvGitHubToken
+		if len(f.token) == 0 {
+			var err error
+			f.token, source, err = FindToken(f.tokenSources(errStream), f.apiHost())
+			if err != nil {
+				PrintRedf(errStream, "Failed to set up ghr: failed to read token: %s\n", err)
+				return ExitCodeTokenNotFound
+			}
+			if len(f.token) == 0 {
+				PrintRedf(errStream, "Failed to set up ghr: token not found\n")
+				fmt.Fprintf(errStream,
+					"To use ghr, you need a GitHub API token.\n"+
+						"Please set it via `%s` env var or `-t` option, or\n"+
+						"`-token-command`, gh CLI, ~/.netrc or `github.token` in gitconfig.\n\n"+
+						"If you don't have one, visit official doc (goo.gl/jSnoI)\n"+
+						"and get it first.\n",
+					EnvGitHubToken)
+				return ExitCodeTokenNotFound
+			}
+		}
+		Debugf("Github API Token: %s (from %s)", maskString(f.token), source)
+	}
+
+	if err := f.retryPolicy().Validate(); err != nil {
+		PrintRedf(errStream, "Invalid retry options: %s\n", err)
+		return ExitCodeBadArgs
+	}
+
+	return ExitCodeOK
+}
+
+// tokenSources returns the sources of the token which are tried in order
+// when it's not given by flags or env vars. errStream receives the stderr of
+// -token-command.
+func (f *repositoryFlags) tokenSources(errStream io.Writer) []TokenSource {
+	var sources []TokenSource
+	if len(f.tokenCommand) != 0 {
+		sources = append(sources, CommandTokenSource(f.tokenCommand, errStream))
+	}
+	return append(sources, GHHostsTokenSource(), NetrcTokenSource(), GitConfigTokenSource())
+}
+
+// apiHost returns the host of the GitHub API, e.g., api.github.com.
+func (f *repositoryFlags) apiHost() string {
+	baseURLStr := defaultBaseURL
+	if len(f.apiURL) != 0 {
+		baseURLStr = f.apiURL
+	}
+	u, err := url.Parse(baseURLStr)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// newClient resolves the repository and creates its client for the
+// subcommand. It prints why and returns the exit code when it fails, or
+// ExitCodeOK.
+func (f *repositoryFlags) newClient(ctx context.Context, errStream io.Writer) (GitHub, int) {
+	if code := f.resolve(errStream); code != ExitCodeOK {
+		return nil, code
+	}
+
+	gitHubClient, err := f.newGitHubClient(ctx)
+	if err != nil {
+		PrintRedf(errStream, "Failed to construct GitHub client: %s\n", err)
+		return nil, ExitCodeError
+	}
+	return gitHubClient, ExitCodeOK
+}
+
+func (f *repositoryFlags) retryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = f.retryMaxAttempts
+	policy.MaxWait = f.retryMaxWait
+	return policy
+}
+
+// newGitHubClient creates the client of the repository. The base URL of the
+// GitHub API can be set via -api-url or env var for use with GitHub
+// Enterprise. ctx cancels the requests for installation tokens of the GitHub
+// App.
+func (f *repositoryFlags) newGitHubClient(ctx context.Context) (GitHub, error) {
+	baseURLStr := defaultBaseURL
+	if len(f.apiURL) != 0 {
+		baseURLStr = f.apiURL
+	}
+	Debugf("Base GitHub API URL: %s", baseURLStr)
+
+	var (
+		gitHubClient GitHub
+		err          error
+	)
+	if f.appKey != nil {
+		gitHubClient, err = f.newAppGitHubClient(ctx, baseURLStr)
+	} else {
+		gitHubClient, err = NewGitHubClient(f.owner, f.repo, f.token, baseURLStr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	policy := f.retryPolicy()
+	Debugf("Retry: max %d attempts, max wait %s", policy.MaxAttempts, policy.MaxWait)
+	if c, ok := gitHubClient.(*GitHubClient); ok {
+		c.RetryPolicy = policy
+	}
+	return gitHubClient, nil
+}
+
+// newAppGitHubClient creates the client which authenticates as the
+// installation of the GitHub App. Installation tokens expire in an hour and
+// are refreshed during long uploads.
+func (f *repositoryFlags) newAppGitHubClient(ctx context.Context, baseURLStr string) (GitHub, error) {
+	baseURL, err := url.ParseRequestURI(baseURLStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Github API URL: %w", err)
+	}
+
+	ts := NewAppTokenSource(&AppTokenSource{
+		AppID:          f.appID,
+		PrivateKey:     f.appKey,
+		InstallationID: f.installationID,
+		Owner:          f.owner,
+		Repo:           f.repo,
+		BaseURL:        baseURL,
+		Ctx:            ctx,
+		RetryPolicy:    f.retryPolicy(),
+	})
+	return NewGitHubClientWithTokenSource(f.owner, f.repo, ts, baseURLStr)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = time.Second
+	defaultRetryMaxWait     = time.Minute
+)
+
+// RetryPolicy decides whether and when a failed request to the GitHub API is
+// retried. Delays grow exponentially with jitter, and the wait requested by
+// GitHub via Retry-After or X-RateLimit-Reset headers is honored.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. It's doubled for each
+	// following retry.
+	BaseDelay time.Duration
+
+	// MaxWait is the maximum delay before a retry. When GitHub asks to wait
+	// longer than this, the request is not retried.
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy used unless configured.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: defaultRetryMaxAttempts,
+	BaseDelay:   defaultRetryBaseDelay,
+	MaxWait:     defaultRetryMaxWait,
+}
+
+// Do calls fn until it succeeds, fails with an error which is not worth
+// retrying, or the attempts run out. It returns the error of the last call.
+//
+// idempotent tells whether the request can be sent again safely after a
+// server error or a network error. Requests rejected by rate limiting are
+// always retried since they are not processed by GitHub.
+func (p RetryPolicy) Do(ctx context.Context, idempotent bool, fn func() (*github.Response, error)) error {
+	for attempt := 1; ; attempt++ {
+		res, err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= p.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		delay, ok := p.delay(attempt, res, err, idempotent)
+		if !ok {
+			return err
+		}
+
+		Debugf("Retry in %s (attempt %d/%d): %s", delay, attempt+1, p.MaxAttempts, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// delay returns the delay before the next attempt, or false when the request
+// should not be retried.
+func (p RetryPolicy) delay(attempt int, res *github.Response, err error, idempotent bool) (time.Duration, bool) {
+	backoff := p.backoff(attempt)
+
+	var (
+		rateLimitErr      *github.RateLimitError
+		abuseRateLimitErr *github.AbuseRateLimitError
+	)
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return 0, false
+
+	case errors.As(err, &rateLimitErr):
+		return p.wait(time.Until(rateLimitErr.Rate.Reset.Time))
+
+	case errors.As(err, &abuseRateLimitErr):
+		if abuseRateLimitErr.RetryAfter != nil {
+			return p.wait(*abuseRateLimitErr.RetryAfter)
+		}
+		return backoff, true
+
+	case res == nil || res.Response == nil:
+		// Network error. The request may have been processed.
+		return backoff, idempotent
+	}
+
+	switch code := res.StatusCode; {
+	case code == http.StatusTooManyRequests:
+		if d, ok := retryAfter(res.Response); ok {
+			return p.wait(d)
+		}
+		return backoff, true
+
+	case code >= 500:
+		return backoff, idempotent
+	}
+
+	return 0, false
+}
+
+// backoff returns the exponential backoff with jitter for the attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxWait {
+		d = p.MaxWait
+	}
+	// Randomize between [d/2, d) not to retry at the same time as other
+	// clients.
+	if d > 1 {
+		d = d/2 + rand.N(d/2)
+	}
+	return d
+}
+
+// wait returns d when it's within MaxWait.
+func (p RetryPolicy) wait(d time.Duration) (time.Duration, bool) {
+	if d < 0 {
+		d = 0
+	}
+	if d > p.MaxWait {
+		Debugf("Not retry: GitHub asks to wait %s which is longer than %s", d, p.MaxWait)
+		return 0, false
+	}
+	return d, true
+}
+
+// retryAfter returns the wait requested by the Retry-After or
+// X-RateLimit-Reset header.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if v := res.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t), true
+		}
+	}
+
+	if v := res.Header.Get("X-RateLimit-Reset"); v != "" {
+		if reset, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0)), true
+		}
+	}
+
+	return 0, false
+}
+
+// Validate checks the policy configured by the user.
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("max attempts must be 1 or more: %d", p.MaxAttempts)
+	}
+	if p.MaxWait < 0 {
+		return fmt.Errorf("max wait must not be negative: %s", p.MaxWait)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func testResponse(code int, header http.Header) *github.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &github.Response{Response: &http.Response{StatusCode: code, Header: header}}
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxWait: time.Minute}
+	errTest := errors.New("error")
+
+	abuseRetryAfter := 30 * time.Second
+	retryAfter := func(v string) http.Header {
+		return http.Header{"Retry-After": []string{v}}
+	}
+
+	cases := []struct {
+		res        *github.Response
+		err        error
+		idempotent bool
+		min, max   time.Duration
+		retry      bool
+	}{
+		// 0: Network errors are retried when idempotent.
+		{nil, errTest, true, 500 * time.Millisecond, time.Second, true},
+
+		// 1: Network errors are not retried when not idempotent.
+		{nil, errTest, false, 0, 0, false},
+
+		// 2: Server errors are retried when idempotent.
+		{testResponse(http.StatusBadGateway, nil), errTest, true, 500 * time.Millisecond, time.Second, true},
+
+		// 3: Server errors are not retried when not idempotent.
+		{testResponse(http.StatusBadGateway, nil), errTest, false, 0, 0, false},
+
+		// 4: Client errors are not retried.
+		{testResponse(http.StatusNotFound, nil), errTest, true, 0, 0, false},
+
+		// 5: 429 honors Retry-After even when not idempotent.
+		{testResponse(http.StatusTooManyRequests, retryAfter("10")), errTest, false, 10 * time.Second, 10 * time.Second, true},
+
+		// 6: 429 without headers uses the backoff.
+		{testResponse(http.StatusTooManyRequests, nil), errTest, false, 500 * time.Millisecond, time.Second, true},
+
+		// 7: Waits longer than MaxWait are not retried.
+		{testResponse(http.StatusTooManyRequests, retryAfter("3600")), errTest, true, 0, 0, false},
+
+		// 8: Canceled requests are not retried.
+		{nil, context.Canceled, true, 0, 0, false},
+
+		// 9: Secondary rate limit honors RetryAfter.
+		{
+			testResponse(http.StatusForbidden, nil),
+			&github.AbuseRateLimitError{RetryAfter: &abuseRetryAfter},
+			false, 30 * time.Second, 30 * time.Second, true,
+		},
+
+		// 10: Primary rate limit waits until the reset.
+		{
+			testResponse(http.StatusForbidden, nil),
+			&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Now().Add(20 * time.Second)}}},
+			false, 18 * time.Second, 20 * time.Second, true,
+		},
+	}
+
+	for i, tc := range cases {
+		d, retry := policy.delay(1, tc.res, tc.err, tc.idempotent)
+		if retry != tc.retry {
+			t.Fatalf("#%d retry = %t; want %t", i, retry, tc.retry)
+		}
+		if retry && (d < tc.min || d > tc.max) {
+			t.Fatalf("#%d delay = %s; want between %s and %s", i, d, tc.min, tc.max)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second).Unix()
+	header := http.Header{"X-Ratelimit-Reset": []string{strconv.FormatInt(reset, 10)}}
+
+	d, ok := retryAfter(&http.Response{Header: header})
+	if !ok {
+		t.Fatal("expect X-RateLimit-Reset to be used")
+	}
+	if d <= 25*time.Second || d > 30*time.Second {
+		t.Fatalf("retryAfter = %s; want about 30s", d)
+	}
+
+	if _, ok := retryAfter(&http.Response{Header: http.Header{}}); ok {
+		t.Fatal("expect no wait without headers")
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxWait: time.Second}
+
+	cases := []struct {
+		code       int
+		idempotent bool
+		want       int
+	}{
+		// 0: Server errors are retried up to MaxAttempts.
+		{http.StatusInternalServerError, true, 3},
+
+		// 1: Server errors are not retried when not idempotent.
+		{http.StatusInternalServerError, false, 1},
+
+		// 2: Rate limited requests are retried even when not idempotent.
+		{http.StatusTooManyRequests, false, 3},
+
+		// 3: Client errors are not retried.
+		{http.StatusUnprocessableEntity, true, 1},
+	}
+
+	for i, tc := range cases {
+		attempts := 0
+		errTest := errors.New("error")
+		err := policy.Do(context.Background(), tc.idempotent, func() (*github.Response, error) {
+			attempts++
+			return testResponse(tc.code, nil), errTest
+		})
+		if !errors.Is(err, errTest) {
+			t.Fatalf("#%d Do returns %v; want the last error", i, err)
+		}
+		if attempts != tc.want {
+			t.Fatalf("#%d attempts = %d; want %d", i, attempts, tc.want)
+		}
+	}
+
+	attempts := 0
+	err := policy.Do(context.Background(), true, func() (*github.Response, error) {
+		attempts++
+		if attempts < 2 {
+			return testResponse(http.StatusBadGateway, nil), errors.New("error")
+		}
+		return testResponse(http.StatusOK, nil), nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("Do = %v after %d attempts; want success after 2 attempts", err, attempts)
+	}
+}
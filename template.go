@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ReleaseTemplateData is the data given to the templates of the release name
+// and body (e.g., '-name "MyTool {{.Version}} ({{.Date}})"').
+type ReleaseTemplateData struct {
+	// Tag is the tag of the release, e.g., "v1.2.0-rc.1".
+	Tag string
+
+	// Version is Tag without the leading 'v', e.g., "1.2.0-rc.1". Major,
+	// Minor, Patch and Prerelease are its parts when it's a valid semver.
+	Version             string
+	Major, Minor, Patch int64
+	Prerelease          string
+
+	Commitish   string
+	Owner, Repo string
+
+	// Date is the date of the release in the form of YYYY-MM-DD.
+	Date string
+
+	// Assets are the artifacts to upload.
+	Assets []*TemplateAsset
+
+	ctx       context.Context
+	github    GitHub
+	tagPrefix string
+
+	once        sync.Once
+	previousTag string
+	previousErr error
+}
+
+// NewReleaseTemplateData creates the data of the release of tag. tagPrefix is
+// removed from tags to get their versions as in '-latest auto', and algo is
+// the algorithm of the checksums of the assets.
+func NewReleaseTemplateData(ctx context.Context, gitHub GitHub, tag, tagPrefix, commitish, owner, repo string,
+	localAssets []string, algo ChecksumAlgorithm) *ReleaseTemplateData {
+	d := &ReleaseTemplateData{
+		Tag:       tag,
+		Version:   strings.TrimPrefix(strings.TrimPrefix(tag, tagPrefix), "v"),
+		Commitish: commitish,
+		Owner:     owner,
+		Repo:      repo,
+		Date:      time.Now().Format(time.DateOnly),
+		ctx:       ctx,
+		github:    gitHub,
+		tagPrefix: tagPrefix,
+	}
+
+	if v, err := version.NewSemver(d.Version); err == nil {
+		segments := v.Segments64()
+		d.Major, d.Minor, d.Patch = segments[0], segments[1], segments[2]
+		d.Prerelease = v.Prerelease()
+	}
+
+	for _, localAsset := range localAssets {
+		d.Assets = append(d.Assets, &TemplateAsset{
+			Name: filepath.Base(localAsset),
+			path: localAsset,
+			algo: algo,
+		})
+	}
+	return d
+}
+
+// PreviousTag returns the tag of the release preceding this one, or an empty
+// string when there is none. It's requested from GitHub only when a template
+// uses it.
+//
+// When the tag is a semver, it's the tag of the highest published release
+// lower than it, so a backport release (e.g., v1.4.3 after v2.0.0) refers to
+// v1.4.2. Prereleases are skipped unless this tag is a prerelease too.
+// Otherwise it's the tag of the latest release.
+func (d *ReleaseTemplateData) PreviousTag() (string, error) {
+	d.once.Do(func() {
+		d.previousTag, d.previousErr = d.findPreviousTag()
+		if d.previousErr != nil {
+			d.previousErr = fmt.Errorf("failed to get the previous release: %w", d.previousErr)
+		}
+	})
+	return d.previousTag, d.previousErr
+}
+
+func (d *ReleaseTemplateData) findPreviousTag() (string, error) {
+	current, err := tagVersion(d.Tag, d.tagPrefix)
+	if err != nil {
+		Debugf("PreviousTag: use the latest release: %s", err)
+		release, err := d.github.GetLatestRelease(d.ctx)
+		if err != nil {
+			if errors.Is(err, ErrReleaseNotFound) {
+				return "", nil
+			}
+			return "", err
+		}
+		// The release being created is not its own previous one, e.g.,
+		// with -recreate or -replace.
+		if release.GetTagName() == d.Tag {
+			return "", nil
+		}
+		return release.GetTagName(), nil
+	}
+
+	releases, err := d.github.ListReleases(d.ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		previous string
+		highest  *version.Version
+	)
+	for _, release := range releases {
+		if release.GetDraft() {
+			continue
+		}
+		v, err := tagVersion(release.GetTagName(), d.tagPrefix)
+		if err != nil || !v.LessThan(current) {
+			continue
+		}
+		if current.Prerelease() == "" && (release.GetPrerelease() || v.Prerelease() != "") {
+			continue
+		}
+		if highest == nil || v.GreaterThan(highest) {
+			previous, highest = release.GetTagName(), v
+		}
+	}
+	return previous, nil
+}
+
+// TemplateAsset is an artifact given to the templates.
+type TemplateAsset struct {
+	Name string
+	path string
+	algo ChecksumAlgorithm
+
+	once     sync.Once
+	checksum string
+	err      error
+}
+
+// Size returns the size of the artifact in bytes.
+func (a *TemplateAsset) Size() (int64, error) {
+	fi, err := os.Stat(a.path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// Checksum returns the checksum of the artifact by '-checksum-algorithm'. It's
+// computed only when a template uses it.
+func (a *TemplateAsset) Checksum() (string, error) {
+	a.once.Do(func() {
+		a.checksum, a.err = FileChecksum(a.path, a.algo)
+	})
+	return a.checksum, a.err
+}
+
+// ParseReleaseTemplate parses text of the release name or body as a template.
+func ParseReleaseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template of %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// ExecuteReleaseTemplate renders the template with the data.
+func ExecuteReleaseTemplate(tmpl *template.Template, data *ReleaseTemplateData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
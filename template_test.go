@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestExecuteReleaseTemplate(t *testing.T) {
+	localAssets := []string{filepath.Join(TestDir, "darwin_386")}
+	sha256sum, err := FileChecksum(localAssets[0], checksumSHA256)
+	if err != nil {
+		t.Fatalf("FileChecksum failed: %s", err)
+	}
+	sha512sum, err := FileChecksum(localAssets[0], checksumSHA512)
+	if err != nil {
+		t.Fatalf("FileChecksum failed: %s", err)
+	}
+
+	release := func(id int64, tag string, prerelease bool) *github.RepositoryRelease {
+		return &github.RepositoryRelease{ID: github.Int64(id), TagName: github.String(tag), Prerelease: github.Bool(prerelease)}
+	}
+	releases := &releasesGitHub{
+		releases: []*github.RepositoryRelease{
+			release(6, "nightly", false),
+			release(5, "v2.1.0-rc.1", true),
+			release(4, "v2.0.0", false),
+			release(3, "cli/v1.5.0", false),
+			release(2, "v1.4.2", false),
+			release(1, "v1.4.1", false),
+		},
+		latestID: 4,
+	}
+
+	cases := []struct {
+		tag, prefix string
+		algo        ChecksumAlgorithm
+		text        string
+		want        string
+	}{
+		// 0: Parts of the version
+		{"v1.2.3-rc.1", "", checksumSHA256, "{{.Version}} {{.Major}}.{{.Minor}}.{{.Patch}} {{.Prerelease}}", "1.2.3-rc.1 1.2.3 rc.1"},
+
+		// 1: Non-semver tags
+		{"nightly", "", checksumSHA256, "{{.Tag}} {{.Version}} {{.Major}}", "nightly nightly 0"},
+
+		// 2: Repository
+		{"v1.0.0", "", checksumSHA256, "{{.Owner}}/{{.Repo}}@{{.Commitish}}", "tcnksm/ghr@main"},
+
+		// 3: Previous tag, skipping prereleases for a stable version
+		{"v2.1.0", "", checksumSHA256, "{{.PreviousTag}}..{{.Tag}}", "v2.0.0..v2.1.0"},
+
+		// 4: A backport release refers to the release lower than it
+		{"v1.4.3", "", checksumSHA256, "{{.PreviousTag}}", "v1.4.2"},
+
+		// 5: A prerelease refers to prereleases too
+		{"v2.1.0-rc.2", "", checksumSHA256, "{{.PreviousTag}}", "v2.1.0-rc.1"},
+
+		// 6: The release itself is not the previous one
+		{"v2.0.0", "", checksumSHA256, "{{.PreviousTag}}", "v1.4.2"},
+
+		// 7: No previous release
+		{"v1.0.0", "", checksumSHA256, "[{{.PreviousTag}}]", "[]"},
+
+		// 8: Tag prefix
+		{"cli/v1.6.0", "cli/", checksumSHA256, "{{.Version}} {{.PreviousTag}}", "1.6.0 cli/v1.5.0"},
+
+		// 9: The latest release for a non-semver tag
+		{"nightly-2", "", checksumSHA256, "{{.PreviousTag}}", "v2.0.0"},
+
+		// 10: Assets
+		{"v1.0.0", "", checksumSHA256, "{{range .Assets}}{{.Name}} {{.Size}} {{.Checksum}}{{end}}", "darwin_386 11 " + sha256sum},
+
+		// 11: Checksums by the algorithm
+		{"v1.0.0", "", checksumSHA512, "{{range .Assets}}{{.Checksum}}{{end}}", sha512sum},
+	}
+
+	for i, tc := range cases {
+		tmpl, err := ParseReleaseTemplate("name", tc.text)
+		if err != nil {
+			t.Fatalf("#%d ParseReleaseTemplate failed: %s", i, err)
+		}
+
+		data := NewReleaseTemplateData(context.TODO(), releases,
+			tc.tag, tc.prefix, "main", "tcnksm", "ghr", localAssets, tc.algo)
+		got, err := ExecuteReleaseTemplate(tmpl, data)
+		if err != nil {
+			t.Fatalf("#%d ExecuteReleaseTemplate failed: %s", i, err)
+		}
+		if got != tc.want {
+			t.Fatalf("#%d ExecuteReleaseTemplate = %q; want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestExecuteReleaseTemplate_unknown(t *testing.T) {
+	if _, err := ParseReleaseTemplate("name", "{{.Tag"); err == nil {
+		t.Fatal("expect ParseReleaseTemplate to fail")
+	}
+
+	tmpl, err := ParseReleaseTemplate("name", "{{.Unknown}}")
+	if err != nil {
+		t.Fatalf("ParseReleaseTemplate failed: %s", err)
+	}
+	data := NewReleaseTemplateData(context.TODO(), &releasesGitHub{}, "v1.0.0", "", "", "", "", nil, checksumSHA256)
+	_, err = ExecuteReleaseTemplate(tmpl, data)
+	if err == nil || !strings.Contains(err.Error(), "Unknown") {
+		t.Fatalf("expect ExecuteReleaseTemplate to fail with the unknown field: %v", err)
+	}
+}
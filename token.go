@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/tcnksm/go-gitconfig"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvGHConfigDir is an environment var containing the config directory
+	// of the gh CLI.
+	EnvGHConfigDir = "GH_CONFIG_DIR"
+
+	// EnvNetrc is an environment var containing the path to the netrc file.
+	EnvNetrc = "NETRC"
+)
+
+// TokenSource is a source of the GitHub API token, tried in order when the
+// token is not given by flags or env vars.
+type TokenSource struct {
+	Name string
+
+	// Token returns the token for the API host, e.g., api.github.com, or
+	// "" if the source has none.
+	Token func(apiHost string) (string, error)
+}
+
+// FindToken returns the token of the first source which has one and the
+// name of the source.
+func FindToken(sources []TokenSource, apiHost string) (string, string, error) {
+	for _, source := range sources {
+		token, err := source.Token(apiHost)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", source.Name, err)
+		}
+		if token != "" {
+			return token, source.Name, nil
+		}
+		Debugf("Token: no token in %s", source.Name)
+	}
+	return "", "", nil
+}
+
+// CommandTokenSource runs the command, e.g., of a password manager, with the
+// shell and reads the token from its stdout. Its stderr, e.g., a prompt, is
+// written to errStream.
+func CommandTokenSource(command string, errStream io.Writer) TokenSource {
+	return TokenSource{
+		Name: "-token-command",
+		Token: func(string) (string, error) {
+			var cmd *exec.Cmd
+			if runtime.GOOS == "windows" {
+				cmd = exec.Command("cmd", "/C", command)
+			} else {
+				cmd = exec.Command("sh", "-c", command)
+			}
+			var stdout bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = errStream
+			if err := cmd.Run(); err != nil {
+				return "", fmt.Errorf("failed to run %q: %w", command, err)
+			}
+
+			token := strings.TrimSpace(stdout.String())
+			if token == "" {
+				return "", fmt.Errorf("%q prints no token", command)
+			}
+			return token, nil
+		},
+	}
+}
+
+// GHHostsTokenSource reads the token of the gh CLI for the host of GitHub
+// from hosts.yml in its config directory. Tokens which gh keeps in the
+// system keyring are not read.
+func GHHostsTokenSource() TokenSource {
+	return TokenSource{
+		Name: "gh hosts.yml",
+		Token: func(apiHost string) (string, error) {
+			data, err := readGHHosts()
+			if err != nil || data == nil {
+				return "", err
+			}
+			return ghHostsToken(data, webHost(apiHost))
+		},
+	}
+}
+
+// readGHHosts reads hosts.yml in the config directory of the gh CLI. It
+// returns nil without error when there is none.
+func readGHHosts() ([]byte, error) {
+	dir := os.Getenv(EnvGHConfigDir)
+	if dir == "" {
+		dir = os.Getenv(EnvXDGConfigHome)
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, nil
+			}
+			dir = filepath.Join(home, ".config")
+		}
+		dir = filepath.Join(dir, "gh")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// GHHostKnown reports whether the gh CLI is logged in to the host of GitHub,
+// i.e., hosts.yml of the gh CLI has it.
+func GHHostKnown(host string) bool {
+	data, err := readGHHosts()
+	if err != nil || data == nil {
+		return false
+	}
+	var hosts map[string]interface{}
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return false
+	}
+	_, ok := hosts[host]
+	return ok
+}
+
+func ghHostsToken(data []byte, host string) (string, error) {
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+		User       string `yaml:"user"`
+		Users      map[string]struct {
+			OAuthToken string `yaml:"oauth_token"`
+		} `yaml:"users"`
+	}
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return "", fmt.Errorf("failed to parse hosts.yml: %w", err)
+	}
+
+	h, ok := hosts[host]
+	if !ok {
+		return "", nil
+	}
+	if h.OAuthToken != "" {
+		return h.OAuthToken, nil
+	}
+	return h.Users[h.User].OAuthToken, nil
+}
+
+// webHost returns the host of GitHub for its API host, e.g., github.com for
+// api.github.com.
+func webHost(apiHost string) string {
+	if apiHost == "api."+gitHubHost {
+		return gitHubHost
+	}
+	if host, ok := strings.CutPrefix(apiHost, "api."); ok && strings.HasSuffix(host, ".ghe.com") {
+		return host
+	}
+	return apiHost
+}
+
+// NetrcTokenSource reads the password of the machine of the API host from
+// the netrc file, $NETRC or ~/.netrc.
+func NetrcTokenSource() TokenSource {
+	return TokenSource{
+		Name: "netrc",
+		Token: func(apiHost string) (string, error) {
+			path := os.Getenv(EnvNetrc)
+			if path == "" {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return "", nil
+				}
+				name := ".netrc"
+				if runtime.GOOS == "windows" {
+					name = "_netrc"
+				}
+				path = filepath.Join(home, name)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return "", nil
+				}
+				return "", err
+			}
+			return netrcPassword(data, apiHost), nil
+		},
+	}
+}
+
+// netrcPassword returns the password of the machine in the netrc file. The
+// default entry is not used, so that the token is not sent to other hosts.
+func netrcPassword(data []byte, machine string) string {
+	var (
+		current  string
+		password string
+		macdef   bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A macro definition continues until an empty line.
+		if macdef {
+			macdef = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "machine", "default":
+				if current == machine && password != "" {
+					return password
+				}
+				current, password = "", ""
+				if fields[i] == "machine" && i+1 < len(fields) {
+					i++
+					current = fields[i]
+				}
+			case "password":
+				if i+1 < len(fields) {
+					i++
+					password = fields[i]
+				}
+			case "login", "account":
+				i++
+			case "macdef":
+				macdef = true
+				i = len(fields)
+			}
+		}
+	}
+
+	if current == machine {
+		return password
+	}
+	return ""
+}
+
+// GitConfigTokenSource reads github.token from gitconfig.
+func GitConfigTokenSource() TokenSource {
+	return TokenSource{
+		Name: "gitconfig github.token",
+		Token: func(string) (string, error) {
+			token, err := gitconfig.GithubToken()
+			if err != nil {
+				// The key is not found.
+				return "", nil
+			}
+			return token, nil
+		},
+	}
+}
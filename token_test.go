@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFindToken(t *testing.T) {
+	source := func(name, token string, err error) TokenSource {
+		return TokenSource{Name: name, Token: func(string) (string, error) { return token, err }}
+	}
+
+	token, name, err := FindToken([]TokenSource{
+		source("empty", "", nil),
+		source("first", "ghp_first", nil),
+		source("second", "ghp_second", nil),
+	}, "api.github.com")
+	if err != nil {
+		t.Fatalf("FindToken failed: %s", err)
+	}
+	if token != "ghp_first" || name != "first" {
+		t.Fatalf("FindToken = %q from %q; want ghp_first from first", token, name)
+	}
+
+	if token, _, err := FindToken([]TokenSource{source("empty", "", nil)}, "api.github.com"); err != nil || token != "" {
+		t.Fatalf("FindToken = %q, %v; want none", token, err)
+	}
+
+	if _, _, err := FindToken([]TokenSource{source("broken", "", errors.New("boom"))}, "api.github.com"); err == nil {
+		t.Fatal("expect FindToken to fail")
+	}
+}
+
+func TestCommandTokenSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands need sh")
+	}
+
+	var stderr bytes.Buffer
+	token, err := CommandTokenSource("echo '  ghp_command  '; echo unlocked >&2", &stderr).Token("api.github.com")
+	if err != nil {
+		t.Fatalf("Token failed: %s", err)
+	}
+	if token != "ghp_command" {
+		t.Fatalf("Token = %q; want ghp_command", token)
+	}
+	if got := stderr.String(); got != "unlocked\n" {
+		t.Fatalf("stderr = %q; want the one of the command", got)
+	}
+
+	for _, command := range []string{"exit 1", "true"} {
+		if _, err := CommandTokenSource(command, io.Discard).Token("api.github.com"); err == nil {
+			t.Fatalf("expect Token to fail with %q", command)
+		}
+	}
+}
+
+func TestGHHostsTokenSource(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvGHConfigDir, dir)
+
+	hosts := `github.com:
+    user: tcnksm
+    git_protocol: https
+    users:
+        tcnksm:
+            oauth_token: gho_multi
+github.example.com:
+    oauth_token: gho_ghe
+    user: tcnksm
+octocorp.ghe.com:
+    user: tcnksm
+`
+	if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(hosts), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"api.github.com":         "gho_multi",
+		"github.example.com":     "gho_ghe",
+		"api.octocorp.ghe.com":   "",
+		"github.unknown.example": "",
+	}
+	for apiHost, want := range cases {
+		got, err := GHHostsTokenSource().Token(apiHost)
+		if err != nil {
+			t.Fatalf("Token(%q) failed: %s", apiHost, err)
+		}
+		if got != want {
+			t.Fatalf("Token(%q) = %q; want %q", apiHost, got, want)
+		}
+	}
+
+	t.Setenv(EnvGHConfigDir, t.TempDir())
+	if got, err := GHHostsTokenSource().Token("api.github.com"); err != nil || got != "" {
+		t.Fatalf("Token without hosts.yml = %q, %v; want none", got, err)
+	}
+}
+
+func TestNetrcTokenSource(t *testing.T) {
+	netrc := `machine api.github.com
+  login tcnksm
+  password ghp_netrc
+
+macdef init
+machine github.example.com password ghp_fake
+
+machine github.example.com login tcnksm password ghp_ghe
+default login anonymous password guest
+`
+	path := filepath.Join(t.TempDir(), ".netrc")
+	if err := os.WriteFile(path, []byte(netrc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvNetrc, path)
+
+	cases := map[string]string{
+		"api.github.com":     "ghp_netrc",
+		"github.example.com": "ghp_ghe",
+		"github.unknown.com": "",
+	}
+	for apiHost, want := range cases {
+		got, err := NetrcTokenSource().Token(apiHost)
+		if err != nil {
+			t.Fatalf("Token(%q) failed: %s", apiHost, err)
+		}
+		if got != want {
+			t.Fatalf("Token(%q) = %q; want %q", apiHost, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// UpdateRelease applies the non-nil fields of req to the existing release and
+// prints the fields which change. Nothing is requested when no field changes.
+func (g *GHR) UpdateRelease(ctx context.Context, release, req *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	fmt.Fprintf(g.outStream, "==> Update release %s\n", release.GetTagName())
+
+	changes := &github.RepositoryRelease{}
+	changed := false
+	diffString := func(field string, before string, after *string, set **string) {
+		if after == nil || before == *after {
+			return
+		}
+		writeFieldDiff(g.outStream, field, before, *after)
+		*set = after
+		changed = true
+	}
+	diffString("name", release.GetName(), req.Name, &changes.Name)
+	diffString("body", release.GetBody(), req.Body, &changes.Body)
+	diffString("commitish", release.GetTargetCommitish(), req.TargetCommitish, &changes.TargetCommitish)
+
+	if req.Prerelease != nil && release.GetPrerelease() != *req.Prerelease {
+		fmt.Fprintf(g.outStream, "--> prerelease: %t -> %t\n", release.GetPrerelease(), *req.Prerelease)
+		changes.Prerelease = req.Prerelease
+		changed = true
+	}
+
+	// GitHub does not tell whether a release is marked as latest, so it's
+	// always applied when requested.
+	if req.MakeLatest != nil {
+		fmt.Fprintf(g.outStream, "--> latest: %s\n", *req.MakeLatest)
+		changes.MakeLatest = req.MakeLatest
+		changed = true
+	}
+
+	if !changed {
+		fmt.Fprintln(g.outStream, "--> No changes")
+		return release, nil
+	}
+
+	updated, err := g.GitHub.EditRelease(ctx, release.GetID(), changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update release: %w", err)
+	}
+	return updated, nil
+}
+
+// writeFieldDiff prints the change of a field. A multi-line value is printed
+// as a line diff.
+func writeFieldDiff(w io.Writer, field, before, after string) {
+	if !strings.Contains(before, "\n") && !strings.Contains(after, "\n") {
+		fmt.Fprintf(w, "--> %s: %q -> %q\n", field, before, after)
+		return
+	}
+
+	fmt.Fprintf(w, "--> %s:\n", field)
+	for _, line := range diffLines(splitLines(before), splitLines(after)) {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the lines of a and b prefixed by "- " when they are only
+// in a, "+ " when only in b, and "  " when in both, based on the longest
+// common subsequence.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+ "+b[j])
+	}
+	return lines
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestGHR_UpdateRelease(t *testing.T) {
+	var buf bytes.Buffer
+	ghr := &GHR{
+		GitHub:    &dryRunGitHub{outStream: &buf},
+		outStream: &buf,
+	}
+
+	release := &github.RepositoryRelease{
+		ID:         github.Int64(1),
+		TagName:    github.String("v1.0.0"),
+		Name:       github.String("v1.0.0"),
+		Body:       github.String("- Fix a bug\n- Add a feture\n"),
+		Prerelease: github.Bool(false),
+	}
+	updated, err := ghr.UpdateRelease(context.TODO(), release, &github.RepositoryRelease{
+		Name:       github.String("v1.0.0"),
+		Body:       github.String("- Fix a bug\n- Add a feature\n"),
+		Prerelease: github.Bool(true),
+	})
+	if err != nil {
+		t.Fatalf("UpdateRelease failed: %s", err)
+	}
+	if updated.GetBody() != "- Fix a bug\n- Add a feature\n" || !updated.GetPrerelease() {
+		t.Fatalf("UpdateRelease does not apply the changes: %v", updated)
+	}
+
+	want := strings.Join([]string{
+		"==> Update release v1.0.0",
+		"--> body:",
+		"      - Fix a bug",
+		"    - - Add a feture",
+		"    + - Add a feature",
+		"--> prerelease: false -> true",
+		"[dry-run] edit release 1 (prerelease=true body=28 bytes)",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Fatalf("UpdateRelease prints:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Nothing is requested without changes.
+	buf.Reset()
+	if _, err := ghr.UpdateRelease(context.TODO(), release, &github.RepositoryRelease{
+		Name: github.String("v1.0.0"),
+	}); err != nil {
+		t.Fatalf("UpdateRelease failed: %s", err)
+	}
+	if got, want := buf.String(), "==> Update release v1.0.0\n--> No changes\n"; got != want {
+		t.Fatalf("UpdateRelease prints %q; want %q", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	got := diffLines([]string{"a", "b", "c"}, []string{"a", "c", "d"})
+	want := []string{"  a", "- b", "  c", "+ d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diffLines = %q; want %q", got, want)
+	}
+}